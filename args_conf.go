@@ -0,0 +1,163 @@
+package gconf
+
+import (
+	"strconv"
+	"strings"
+)
+
+type ArgsConfSource struct {
+	args    []string
+	aliases map[string]string
+}
+
+func NewArgsConfSource(args []string) *ArgsConfSource {
+	return &ArgsConfSource{
+		args:    args,
+		aliases: make(map[string]string),
+	}
+}
+
+func (s *ArgsConfSource) Build(builder ConfBuilder) (ConfProvider, error) {
+	return NewConfProvider(s)
+}
+
+func (s *ArgsConfSource) Load() (map[string]interface{}, error) {
+	parser := newArgsConfParser(s.aliases)
+	parser.Parse(s.args)
+
+	return parser.GetDataMap(), nil
+}
+
+// SetAliases adds the aliases of the keys, such as "v" for "logging/printLevel".
+// The aliases are matched without their leading dashes, so "-v" and "--v" are the same alias.
+func (s *ArgsConfSource) SetAliases(aliases map[string]string) *ArgsConfSource {
+	for alias, key := range aliases {
+		s.AddAlias(alias, key)
+	}
+
+	return s
+}
+
+func (s *ArgsConfSource) AddAlias(alias string, key string) *ArgsConfSource {
+	s.aliases[strings.TrimLeft(alias, argsFlagPrefix)] = key
+	return s
+}
+
+// GetUnrecognizedArgs returns the arguments which aren't configuration keys,
+// such as the positional arguments, the flags without value and everything after "--".
+// The value of a flag follows "=", it can be the next argument only for the aliases
+// and the nested keys such as "--logging:printLevel debug".
+func (s *ArgsConfSource) GetUnrecognizedArgs() []string {
+	parser := newArgsConfParser(s.aliases)
+	parser.Parse(s.args)
+
+	return parser.GetUnrecognizedArgs()
+}
+
+const (
+	argsFlagPrefix        string = "-"
+	argsLongFlagPrefix    string = "--"
+	argsTerminator        string = "--"
+	argsKeyValueDelimiter string = "="
+	argsSectionDelimiter  string = ":"
+)
+
+type argsConfParser struct {
+	dataMap      map[string]interface{}
+	aliases      map[string]string
+	unrecognized []string
+}
+
+func newArgsConfParser(aliases map[string]string) *argsConfParser {
+	return &argsConfParser{
+		dataMap: make(map[string]interface{}),
+		aliases: aliases,
+	}
+}
+
+func (p *argsConfParser) Parse(args []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == argsTerminator {
+			p.unrecognized = append(p.unrecognized, args[i+1:]...)
+			return
+		}
+
+		name, ok := p.trimFlagPrefix(arg)
+		if !ok {
+			p.unrecognized = append(p.unrecognized, arg)
+			continue
+		}
+
+		var value string
+
+		if idx := strings.Index(name, argsKeyValueDelimiter); idx != -1 {
+			value = name[idx+1:]
+			name = name[:idx]
+		} else if p.takesValue(name) && i+1 < len(args) && p.isValue(args[i+1]) {
+			value = args[i+1]
+			i++
+		} else {
+			p.unrecognized = append(p.unrecognized, arg)
+			continue
+		}
+
+		if name == "" {
+			p.unrecognized = append(p.unrecognized, arg)
+			continue
+		}
+
+		p.dataMap[p.toKey(name)] = value
+	}
+}
+
+func (p *argsConfParser) trimFlagPrefix(arg string) (string, bool) {
+	if strings.HasPrefix(arg, argsLongFlagPrefix) {
+		return arg[len(argsLongFlagPrefix):], true
+	}
+
+	if strings.HasPrefix(arg, argsFlagPrefix) && len(arg) > 1 && !isNumber(arg) {
+		return arg[len(argsFlagPrefix):], true
+	}
+
+	return "", false
+}
+
+// takesValue tells whether the flag takes the next argument as its value, which is only
+// the case for the aliases and the nested keys such as "logging:printLevel", so the flags
+// such as "--verbose" don't swallow the positional argument which follows them.
+func (p *argsConfParser) takesValue(name string) bool {
+	if _, ok := p.aliases[name]; ok {
+		return true
+	}
+
+	return strings.Contains(name, argsSectionDelimiter) || strings.Contains(name, PathDelimiter)
+}
+
+func (p *argsConfParser) isValue(arg string) bool {
+	return !strings.HasPrefix(arg, argsFlagPrefix) || arg == argsFlagPrefix || isNumber(arg)
+}
+
+func (p *argsConfParser) toKey(name string) string {
+	if key, ok := p.aliases[name]; ok {
+		name = key
+	}
+
+	name = strings.Replace(name, argsSectionDelimiter, PathDelimiter, -1)
+
+	return PathCombine(RootPath, name)
+}
+
+func (p *argsConfParser) GetDataMap() map[string]interface{} {
+	return p.dataMap
+}
+
+func (p *argsConfParser) GetUnrecognizedArgs() []string {
+	return p.unrecognized
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,70 @@
+package gconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArgsConfParser(t *testing.T) {
+	aliases := map[string]string{"v": "logging/printLevel", "q": "quiet"}
+
+	tests := []struct {
+		args         []string
+		expected     map[string]interface{}
+		unrecognized []string
+	}{
+		{[]string{"--logging/printLevel=debug"}, map[string]interface{}{"/logging/printLevel": "debug"}, nil},
+		{[]string{"--logging:printLevel", "debug"}, map[string]interface{}{"/logging/printLevel": "debug"}, nil},
+		{[]string{"--a/b", "v", "pos"}, map[string]interface{}{"/a/b": "v"}, []string{"pos"}},
+		{[]string{"-k=v"}, map[string]interface{}{"/k": "v"}, nil},
+		{[]string{"--k=a=b"}, map[string]interface{}{"/k": "a=b"}, nil},
+		{[]string{"-v", "info"}, map[string]interface{}{"/logging/printLevel": "info"}, nil},
+		{[]string{"--v=warn"}, map[string]interface{}{"/logging/printLevel": "warn"}, nil},
+		{[]string{"--port:number", "-1"}, map[string]interface{}{"/port/number": "-1"}, nil},
+		{[]string{"--verbose", "pos", "--x=1"}, map[string]interface{}{"/x": "1"}, []string{"--verbose", "pos"}},
+		{[]string{"--debug=true", "--verbose"}, map[string]interface{}{"/debug": "true"}, []string{"--verbose"}},
+		{[]string{"-q", "--x=1"}, map[string]interface{}{"/x": "1"}, []string{"-q"}},
+		{[]string{"--a:b", "--c=d"}, map[string]interface{}{"/c": "d"}, []string{"--a:b"}},
+		{[]string{"--=v", "-", "-5"}, map[string]interface{}{}, []string{"--=v", "-", "-5"}},
+		{[]string{"--x=1", "--", "--y=2", "file"}, map[string]interface{}{"/x": "1"}, []string{"--y=2", "file"}},
+	}
+
+	for _, test := range tests {
+		parser := newArgsConfParser(aliases)
+		parser.Parse(test.args)
+
+		if !reflect.DeepEqual(parser.GetDataMap(), test.expected) {
+			t.Errorf("keys of the args %v, expected: %v, returned: %v", test.args, test.expected, parser.GetDataMap())
+		}
+
+		if !reflect.DeepEqual(parser.GetUnrecognizedArgs(), test.unrecognized) {
+			t.Errorf("unrecognized args of %v, expected: %v, returned: %v", test.args, test.unrecognized, parser.GetUnrecognizedArgs())
+		}
+	}
+}
+
+func TestArgsConfSource(t *testing.T) {
+	source := NewArgsConfSource([]string{"-v", "debug", "run", "--logging:fileLevel=error"}).
+		SetAliases(map[string]string{"--v": "logging:printLevel"})
+
+	root, err := NewConfBuilder().
+		Add(NewJsonConfSource([]byte(`{ "logging": { "printLevel": "info", "fileLevel": "info" } }`))).
+		Add(source).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	if level := root.TryGetString("logging/printLevel", ""); level != "debug" {
+		t.Errorf("value of the alias, expected: %s, returned: %s", "debug", level)
+	}
+
+	if level := root.TryGetString("logging/fileLevel", ""); level != "error" {
+		t.Errorf("value of the flag, expected: %s, returned: %s", "error", level)
+	}
+
+	if args := source.GetUnrecognizedArgs(); len(args) != 1 || args[0] != "run" {
+		t.Errorf("unrecognized args, expected: [run], returned: %v", args)
+	}
+}
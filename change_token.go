@@ -1,35 +1,322 @@
 package gconf
 
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+type ChangeTokenRegistration interface {
+	Unregister()
+}
+
+// reloadToken is a one-shot token, it fires its callbacks only on the first change
+// and the producer issues a fresh token for the next change.
 type reloadToken struct {
-	changed  bool
-	callback func()
+	changed   int32
+	callbacks map[uint64]func()
+	lastId    uint64
+	legacy    ChangeTokenRegistration
+	mutex     sync.Mutex
+}
+
+type tokenRegistration struct {
+	token *reloadToken
+	id    uint64
 }
 
 func NewChangeToken() ChangeToken {
+	return newReloadToken()
+}
+
+func newReloadToken() *reloadToken {
 	return &reloadToken{
-		changed:  false,
-		callback: nil,
+		changed:   0,
+		callbacks: make(map[uint64]func()),
 	}
 }
 
+// SetCallback replaces the callback set by the previous call, the callbacks registered
+// by RegisterChangeCallback are kept.
 func (r *reloadToken) SetCallback(callback func()) {
-	r.callback = callback
+	r.mutex.Lock()
+	legacy := r.legacy
+	r.legacy = nil
+	r.mutex.Unlock()
+
+	if legacy != nil {
+		legacy.Unregister()
+	}
+
+	if callback == nil {
+		return
+	}
+
+	registration := r.RegisterChangeCallback(callback)
+
+	r.mutex.Lock()
+	r.legacy = registration
+	r.mutex.Unlock()
+}
+
+// RegisterChangeCallback registers the callback of the change, which is called immediately
+// when the token has already changed.
+func (r *reloadToken) RegisterChangeCallback(callback func()) ChangeTokenRegistration {
+	if callback == nil {
+		return &tokenRegistration{}
+	}
+
+	r.mutex.Lock()
+
+	if r.HasChanged() {
+		r.mutex.Unlock()
+		callback()
+		return &tokenRegistration{}
+	}
+
+	r.lastId++
+	id := r.lastId
+	r.callbacks[id] = callback
+	r.mutex.Unlock()
+
+	return &tokenRegistration{
+		token: r,
+		id:    id,
+	}
 }
 
 func (r *reloadToken) HasChanged() bool {
-	return r.changed
+	return atomic.LoadInt32(&r.changed) == 1
 }
 
+// SetAsChanged marks the token as changed and calls the callbacks like OnChanged.
 func (r *reloadToken) SetAsChanged() {
-	r.changed = true
+	r.OnChanged()
 }
 
+// OnChanged marks the token as changed and calls the callbacks in the order they were registered.
+// It does nothing once the token has changed.
 func (r *reloadToken) OnChanged() {
-	r.SetAsChanged()
+	r.mutex.Lock()
+
+	if !atomic.CompareAndSwapInt32(&r.changed, 0, 1) {
+		r.mutex.Unlock()
+		return
+	}
+
+	callbacks := r.takeCallbacks()
+	r.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// takeCallbacks must be called with the mutex held.
+func (r *reloadToken) takeCallbacks() []func() {
+	ids := make([]uint64, 0, len(r.callbacks))
+	for id := range r.callbacks {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	callbacks := make([]func(), 0, len(ids))
+	for _, id := range ids {
+		callbacks = append(callbacks, r.callbacks[id])
+	}
+
+	r.callbacks = make(map[uint64]func())
+
+	return callbacks
+}
+
+func (r *tokenRegistration) Unregister() {
+	if r.token == nil {
+		return
+	}
+
+	r.token.mutex.Lock()
+	defer r.token.mutex.Unlock()
+
+	delete(r.token.callbacks, r.id)
+}
+
+// CompositeChangeToken changes when any of its children changes.
+type CompositeChangeToken struct {
+	*reloadToken
+	children      []ChangeToken
+	registrations []ChangeTokenRegistration
+	once          sync.Once
+}
+
+func NewCompositeChangeToken(children ...ChangeToken) *CompositeChangeToken {
+	return &CompositeChangeToken{
+		reloadToken: newReloadToken(),
+		children:    children,
+	}
+}
+
+func (c *CompositeChangeToken) GetChildren() []ChangeToken {
+	return c.children
+}
+
+func (c *CompositeChangeToken) HasChanged() bool {
+	if c.reloadToken.HasChanged() {
+		return true
+	}
+
+	for _, child := range c.children {
+		if child.HasChanged() {
+			return true
+		}
+	}
 
-	if r.callback == nil {
+	return false
+}
+
+func (c *CompositeChangeToken) SetCallback(callback func()) {
+	c.bindChildren()
+	c.reloadToken.SetCallback(callback)
+}
+
+func (c *CompositeChangeToken) RegisterChangeCallback(callback func()) ChangeTokenRegistration {
+	c.bindChildren()
+	return c.reloadToken.RegisterChangeCallback(callback)
+}
+
+func (c *CompositeChangeToken) OnChanged() {
+	c.reloadToken.OnChanged()
+
+	c.reloadToken.mutex.Lock()
+	registrations := c.registrations
+	c.registrations = nil
+	c.reloadToken.mutex.Unlock()
+
+	for _, r := range registrations {
+		r.Unregister()
+	}
+}
+
+// bindChildren registers the callbacks on the children when the first callback is registered,
+// so the children don't keep the callbacks of the composites nobody listens to.
+func (c *CompositeChangeToken) bindChildren() {
+	c.once.Do(func() {
+		for _, child := range c.children {
+			registration := child.RegisterChangeCallback(c.OnChanged)
+
+			c.reloadToken.mutex.Lock()
+			c.registrations = append(c.registrations, registration)
+			c.reloadToken.mutex.Unlock()
+		}
+	})
+}
+
+// signalToken passes the events of the watchers to the callback. Unlike the change tokens
+// it isn't one-shot, it calls the callback on every event the watcher raises.
+type signalToken struct {
+	callback func()
+	mutex    sync.Mutex
+}
+
+func newSignalToken(callback func()) *signalToken {
+	return &signalToken{
+		callback: callback,
+	}
+}
+
+func (s *signalToken) SetCallback(callback func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.callback = callback
+}
+
+func (s *signalToken) RegisterChangeCallback(callback func()) ChangeTokenRegistration {
+	s.SetCallback(callback)
+	return &tokenRegistration{}
+}
+
+func (s *signalToken) HasChanged() bool {
+	return false
+}
+
+// SetAsChanged calls the callback like OnChanged, the token keeps no state to mark.
+func (s *signalToken) SetAsChanged() {
+	s.OnChanged()
+}
+
+func (s *signalToken) OnChanged() {
+	s.mutex.Lock()
+	callback := s.callback
+	s.mutex.Unlock()
+
+	if callback != nil {
+		callback()
+	}
+}
+
+// OnChangeToken calls the consumer on every change of the tokens the producer issues,
+// it registers on the fresh token after every change until the registration is unregistered.
+func OnChangeToken(producer func() ChangeToken, consumer func()) ChangeTokenRegistration {
+	r := &tokenChainRegistration{
+		producer: producer,
+		consumer: consumer,
+	}
+
+	r.register()
+
+	return r
+}
+
+type tokenChainRegistration struct {
+	producer     func() ChangeToken
+	consumer     func()
+	registration ChangeTokenRegistration
+	disposed     bool
+	mutex        sync.Mutex
+}
+
+func (r *tokenChainRegistration) register() {
+	token := r.producer()
+	if token == nil {
+		return
+	}
+
+	registration := token.RegisterChangeCallback(r.onChanged)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.disposed {
+		registration.Unregister()
+		return
+	}
+
+	r.registration = registration
+}
+
+func (r *tokenChainRegistration) onChanged() {
+	r.mutex.Lock()
+	disposed := r.disposed
+	r.mutex.Unlock()
+
+	if disposed {
 		return
 	}
 
-	go r.callback()
+	r.consumer()
+	r.register()
+}
+
+func (r *tokenChainRegistration) Unregister() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.disposed = true
+
+	if r.registration != nil {
+		r.registration.Unregister()
+		r.registration = nil
+	}
 }
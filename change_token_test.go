@@ -0,0 +1,127 @@
+package gconf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangeTokenRegistrations(t *testing.T) {
+	token := NewChangeToken()
+
+	var calls []string
+	token.RegisterChangeCallback(func() { calls = append(calls, "first") })
+	removed := token.RegisterChangeCallback(func() { calls = append(calls, "removed") })
+	token.RegisterChangeCallback(func() { calls = append(calls, "second") })
+	token.SetCallback(func() { calls = append(calls, "replaced") })
+	token.SetCallback(func() { calls = append(calls, "legacy") })
+
+	removed.Unregister()
+
+	token.OnChanged()
+	token.OnChanged()
+
+	expected := []string{"first", "second", "legacy"}
+
+	if len(calls) != len(expected) {
+		t.Fatalf("callbacks of the token, expected: %v, returned: %v", expected, calls)
+	}
+
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("callbacks of the token, expected: %v, returned: %v", expected, calls)
+			break
+		}
+	}
+
+	if !token.HasChanged() {
+		t.Errorf("HasChanged of the fired token, expected: true, returned: false")
+	}
+}
+
+func TestCompositeChangeToken(t *testing.T) {
+	first := NewChangeToken()
+	second := NewChangeToken()
+	composite := NewCompositeChangeToken(first, second)
+
+	fired := 0
+	composite.RegisterChangeCallback(func() { fired++ })
+
+	second.OnChanged()
+	first.OnChanged()
+
+	if fired != 1 || !composite.HasChanged() {
+		t.Errorf("composite token, expected: fired once, returned: fired %d times, changed: %v", fired, composite.HasChanged())
+	}
+}
+
+func TestRootChangeToken(t *testing.T) {
+	source := NewMapConfSource(map[string]interface{}{"version": 1})
+
+	root, err := NewConfBuilder().Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	changed := make(chan struct{}, 10)
+	registration := OnChangeToken(root.GetChangeToken, func() { changed <- struct{}{} })
+
+	for i := 2; i <= 3; i++ {
+		source.Set("version", i)
+
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Fatalf("the change of version %d wasn't notified by the token", i)
+		}
+	}
+
+	registration.Unregister()
+	source.Set("version", 4)
+
+	select {
+	case <-changed:
+		t.Errorf("notification after unregistering the callback")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if root.GetChangeToken().HasChanged() {
+		t.Errorf("HasChanged of a fresh token, expected: false, returned: true")
+	}
+}
+
+func TestSetAsChanged(t *testing.T) {
+	token := NewChangeToken()
+
+	fired := 0
+	token.RegisterChangeCallback(func() { fired++ })
+
+	token.SetAsChanged()
+	token.OnChanged()
+
+	if fired != 1 || !token.HasChanged() {
+		t.Errorf("callbacks of the token set as changed, expected: 1, returned: %d", fired)
+	}
+
+	token.RegisterChangeCallback(func() { fired++ })
+
+	if fired != 2 {
+		t.Errorf("callback registered after the change, expected: called immediately, returned: %d calls", fired)
+	}
+}
+
+func TestSignalToken(t *testing.T) {
+	fired := 0
+	token := newSignalToken(func() { fired++ })
+
+	token.OnChanged()
+	token.SetAsChanged()
+	token.OnChanged()
+
+	if fired != 3 {
+		t.Errorf("calls of the signal token, expected: %d, returned: %d", 3, fired)
+	}
+
+	if token.HasChanged() {
+		t.Errorf("HasChanged of the signal token, expected: false, returned: true")
+	}
+}
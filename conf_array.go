@@ -1,14 +1,17 @@
 package gconf
 
-import "log"
+import (
+	"io"
+	"log"
+)
 
 type confArraySection struct {
 	path      string
-	root      ConfRoot
+	root      Conf
 	converter TypeConverter
 }
 
-func NewConfArraySection(root ConfRoot, path string) ConfArraySection {
+func NewConfArraySection(root Conf, path string) ConfArraySection {
 	if root == nil {
 		log.Fatal("[confArraySection::NewConfSection] invalid null argument: root")
 	}
@@ -35,14 +38,43 @@ func (c *confArraySection) GetPath() string {
 	return c.path
 }
 
+func (c *confArraySection) getConfParserRegistry() *ConfParserRegistry {
+	return confParserRegistryOf(c.root)
+}
+
 func (c *confArraySection) Get(key string) interface{} {
 	return c.root.Get(PathCombine(c.path, key))
 }
 
+func (c *confArraySection) getResolved(key string) (interface{}, error) {
+	return resolveValue(c.root, PathCombine(c.path, key))
+}
+
 func (c *confArraySection) Set(key string, value interface{}) error {
 	return c.root.Set(PathCombine(c.path, key), value)
 }
 
+func (c *confArraySection) Delete(key string) error {
+	return c.root.Delete(PathCombine(c.path, key))
+}
+
+func (c *confArraySection) DeleteSection(key string) error {
+	return c.root.DeleteSection(PathCombine(c.path, key))
+}
+
+func (c *confArraySection) Key() string {
+	return GetSectionKey(c.path)
+}
+
+func (c *confArraySection) Value() interface{} {
+	return c.root.Get(c.path)
+}
+
+// GetChildren returns the sections of the elements of the array.
+func (c *confArraySection) GetChildren() []ConfSection {
+	return getChildSections(c.root, c.path, c.root.Keys())
+}
+
 func (c *confArraySection) ContainKey(key string) bool {
 	return c.root.ContainKey(PathCombine(c.path, key))
 }
@@ -67,7 +99,7 @@ func (c *confArraySection) Values() []interface{} {
 	var values []interface{}
 
 	for _, p := range pairs {
-		if HasPathInKey(c.path, p.Key) {
+		if IsKeyInPath(c.path, p.Key) {
 			values = append(values, p.Value)
 		}
 	}
@@ -93,7 +125,7 @@ func (c *confArraySection) IsEmpty() bool {
 	}
 
 	for _, p := range pairs {
-		if HasPathInKey(c.path, p.Key) {
+		if IsKeyInPath(c.path, p.Key) {
 			return false
 		}
 	}
@@ -149,6 +181,10 @@ func (c *confArraySection) GetString(key string) (string, error) {
 	return c.converter.GetString(key)
 }
 
+func (c *confArraySection) GetValue(key string) (interface{}, error) {
+	return c.converter.GetValue(key)
+}
+
 func (c *confArraySection) TryGet(key string, defaultValue interface{}) interface{} {
 	return c.root.TryGet(PathCombine(c.path, key), defaultValue)
 }
@@ -205,6 +241,18 @@ func (c *confArraySection) GetArraySection(key string) ConfArraySection {
 	return NewConfArraySection(c.root, PathCombine(c.path, key))
 }
 
+func (c *confArraySection) Unmarshal(key string, out interface{}) error {
+	return c.converter.Unmarshal(key, out)
+}
+
+func (c *confArraySection) Dump(w io.Writer, format string, options ...DumpOption) error {
+	return c.converter.Dump(w, format, options...)
+}
+
+func (c *confArraySection) Query(pattern string) ([]KeyValuePair, error) {
+	return c.converter.Query(pattern)
+}
+
 func (c *confArraySection) Length() int {
 	return LengthOfArrayPath(c.path, c.Keys())
 }
@@ -1,11 +1,21 @@
 package gconf
 
+import (
+	"errors"
+	"fmt"
+)
+
 type configurationBuilder struct {
-	sources []ConfSource
+	sources    []ConfSource
+	registry   *ConfParserRegistry
+	validators []ConfValidator
+	mergeRules arrayMergeRules
 }
 
 func NewConfBuilder() ConfBuilder {
-	return &configurationBuilder{}
+	return &configurationBuilder{
+		registry: NewConfParserRegistry(defaultConfParserRegistry),
+	}
 }
 
 func (c *configurationBuilder) Add(source ConfSource) ConfBuilder {
@@ -21,7 +31,49 @@ func (c *configurationBuilder) GetSources() []ConfSource {
 	return c.sources
 }
 
+// RegisterConfParser registers the parser for the sources of this builder only,
+// the formats which aren't registered here are looked up in the global registry.
+func (c *configurationBuilder) RegisterConfParser(parser ConfParser, formats ...string) ConfBuilder {
+	c.registry.Register(parser, formats...)
+	return c
+}
+
+func (c *configurationBuilder) GetConfParserRegistry() *ConfParserRegistry {
+	return c.registry
+}
+
+// AddValidator adds the validator of the merged configuration, which is run on Build
+// and before the reload of any provider is committed.
+func (c *configurationBuilder) AddValidator(validator ConfValidator) ConfBuilder {
+	if validator == nil {
+		return c
+	}
+
+	c.validators = append(c.validators, validator)
+	return c
+}
+
+// SetArrayMergePolicy sets how the arrays of the path are merged across the providers,
+// the arrays are replaced by the provider of the higher priority unless a policy is set.
+// The "*" entity of the path matches any entity, such as "/clusters/*/nodes".
+func (c *configurationBuilder) SetArrayMergePolicy(path string, policy ArrayMergePolicy) ConfBuilder {
+	c.mergeRules = append(c.mergeRules, newArrayMergeRule(path, policy, ""))
+	return c
+}
+
+// SetArrayMergeKey merges the elements of the arrays of the path whose key fields are equal,
+// the elements without a match are appended.
+func (c *configurationBuilder) SetArrayMergeKey(path string, keyField string) ConfBuilder {
+	c.mergeRules = append(c.mergeRules, newArrayMergeRule(path, ArrayMergeByKey, keyField))
+	return c
+}
+
 func (c *configurationBuilder) Build() (ConfRoot, error) {
+	for _, r := range c.mergeRules {
+		if err := r.validate(); err != nil {
+			return nil, errors.New(fmt.Sprintf("[ConfBuilder::Build] %s", err.Error()))
+		}
+	}
 
 	var providers []ConfProvider
 
@@ -39,5 +91,15 @@ func (c *configurationBuilder) Build() (ConfRoot, error) {
 		providers = append(providers, provider)
 	}
 
-	return newConfRoot(providers), nil
+	root := newConfRoot(providers, c.validators)
+	root.arrayMergeRules = append(arrayMergeRules(nil), c.mergeRules...)
+	root.registry = c.registry
+
+	if err := validateConf(root, c.validators); err != nil {
+		return nil, errors.New(fmt.Sprintf("[ConfBuilder::Build] the configuration is rejected by the validator: %s", err.Error()))
+	}
+
+	root.bindProviders()
+
+	return root, nil
 }
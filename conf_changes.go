@@ -123,3 +123,20 @@ func changeModeToString(mode ChangeMode) string {
 		return "unknown"
 	}
 }
+
+// FilterConfChanges returns the changes of the keys under the path.
+func FilterConfChanges(changes ConfChanges, path string) ConfChanges {
+	var filtered []Change
+
+	if changes == nil {
+		return newConfChanges(filtered)
+	}
+
+	for _, c := range changes.GetChanges() {
+		if IsKeyInPath(path, c.KeyName) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return newConfChanges(filtered)
+}
@@ -0,0 +1,55 @@
+package gconf
+
+import "testing"
+
+func TestSectionChildren(t *testing.T) {
+	root, err := NewConfBuilder().Add(NewJsonConfSource([]byte(`{
+		"log": { "level": "info" },
+		"logging": { "level": "debug" },
+		"clients": {
+			"orders": { "url": "http://orders", "retries": 3 },
+			"billing": { "url": "http://billing" },
+			"audit": "disabled"
+		},
+		"ports": [80, 81, 82, 83, 84, 85, 86, 87, 88, 89, 90]
+	}`))).Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	if keys := root.GetSection("log").Keys(); len(keys) != 1 || keys[0] != "/log/level" {
+		t.Errorf("keys of the section[/log], expected: [/log/level], returned: %v", keys)
+	}
+
+	children := root.GetSection("clients").GetChildren()
+	expected := []string{"audit", "billing", "orders"}
+
+	if len(children) != len(expected) {
+		t.Fatalf("children of the section[/clients], expected: %v, returned: %d children", expected, len(children))
+	}
+
+	for i, child := range children {
+		if child.Key() != expected[i] {
+			t.Errorf("key of the child %d, expected: %s, returned: %s", i, expected[i], child.Key())
+		}
+	}
+
+	if children[0].Value() != "disabled" || children[1].Value() != nil {
+		t.Errorf("values of the children, expected: [disabled <nil>], returned: [%v %v]", children[0].Value(), children[1].Value())
+	}
+
+	if url := children[2].TryGetString("url", ""); url != "http://orders" {
+		t.Errorf("url of the child[orders], expected: %s, returned: %s", "http://orders", url)
+	}
+
+	ports := root.GetArraySection("ports").GetChildren()
+
+	if len(ports) != 11 || ports[2].Key() != "$2" || ports[10].Value() != float64(90) {
+		t.Errorf("elements of the array, returned: %d children", len(ports))
+	}
+
+	if top := root.GetChildren(); len(top) != 4 || top[0].Key() != "clients" {
+		t.Errorf("top level sections, returned: %d sections", len(top))
+	}
+}
@@ -0,0 +1,152 @@
+package gconf
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRootDelete(t *testing.T) {
+	base := NewMapConfSource(map[string]interface{}{
+		"feature": map[string]interface{}{"enabled": true, "limit": 10},
+	})
+
+	source := NewMapConfSource(map[string]interface{}{
+		"feature": map[string]interface{}{"enabled": false},
+		"servers": []interface{}{
+			map[string]interface{}{"host": "alpha"},
+			map[string]interface{}{"host": "beta"},
+			map[string]interface{}{"host": "gamma"},
+		},
+	})
+
+	root, err := NewConfBuilder().Add(base).Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	changes := make(chan ConfChanges, 10)
+	root.OnChange("", func(c ConfChanges) { changes <- c })
+
+	if err := root.Delete("feature"); err == nil {
+		t.Errorf("Delete of a section, expected: an error, returned: nil")
+	}
+
+	if err := root.Delete("feature/enabled"); err != nil {
+		t.Fatalf("can't delete the key: %s", err.Error())
+	}
+
+	if root.ContainKey("feature/enabled") {
+		t.Errorf("ContainKey of the deleted key, expected: false, returned: true")
+	}
+
+	expectRemoved(t, changes, "/feature/enabled")
+
+	servers := root.GetArraySection("servers")
+
+	if err := servers.DeleteSection(GetArrayIndex(1)); err != nil {
+		t.Fatalf("can't delete the element of the array: %s", err.Error())
+	}
+
+	if servers.Length() != 2 || servers.TryGetString("$1/host", "") != "gamma" {
+		t.Errorf("array after the deletion, expected: [alpha gamma], returned: %v", root.Get("servers"))
+	}
+
+	expectRemoved(t, changes, "/servers/$2/host")
+
+	if err := root.Delete("servers/$0"); err != nil {
+		t.Fatalf("can't delete the element of the array: %s", err.Error())
+	}
+
+	if servers.Length() != 1 || servers.TryGetString("$0/host", "") != "gamma" {
+		t.Errorf("array after the deletion of the element, expected: [gamma], returned: %v", root.Get("servers"))
+	}
+
+	expectRemoved(t, changes, "/servers/$1/host")
+
+	if err := root.DeleteSection("feature"); err != nil {
+		t.Fatalf("can't delete the section: %s", err.Error())
+	}
+
+	if root.ContainKey("feature/limit") || !root.GetSection("feature").IsEmpty() {
+		t.Errorf("section after the deletion, expected: empty, returned: %v", root.GetSection("feature").Keys())
+	}
+
+	if err := root.DeleteSection("feature"); err == nil {
+		t.Errorf("DeleteSection of a missing key, expected: an error, returned: nil")
+	}
+}
+
+func TestRootDeleteMergedArray(t *testing.T) {
+	newServers := func(names ...string) ConfSource {
+		servers := make([]interface{}, 0, len(names))
+
+		for _, n := range names {
+			servers = append(servers, map[string]interface{}{"name": n})
+		}
+
+		return NewMapConfSource(map[string]interface{}{"servers": servers})
+	}
+
+	tests := []struct {
+		setup    func(ConfBuilder)
+		base     []string
+		upper    []string
+		deleted  []string
+		expected []string
+	}{
+		{func(ConfBuilder) {}, []string{"a", "b"}, []string{"c", "d"}, []string{"servers/$0"}, []string{"d"}},
+		{func(b ConfBuilder) { b.SetArrayMergePolicy("servers", ArrayMergeByIndex) },
+			[]string{"a", "b", "e"}, []string{"c", "d"}, []string{"servers/$1"}, []string{"c", "e"}},
+		{func(b ConfBuilder) { b.SetArrayMergePolicy("servers", ArrayAppend) },
+			[]string{"a", "b"}, []string{"c", "d"}, []string{"servers/$1"}, []string{"a", "c", "d"}},
+		{func(b ConfBuilder) { b.SetArrayMergePolicy("servers", ArrayAppend) },
+			[]string{"a", "b"}, []string{"c", "d"}, []string{"servers/$3", "servers/$2/name"}, []string{"a", "b"}},
+		{func(b ConfBuilder) { b.SetArrayMergeKey("servers", "name") },
+			[]string{"a", "b"}, []string{"b", "c"}, []string{"servers/$1"}, []string{"a", "c"}},
+		{func(b ConfBuilder) { b.SetArrayMergeKey("servers", "name") },
+			[]string{"a", "b"}, []string{"b", "c"}, []string{"servers/$2"}, []string{"a", "b"}},
+	}
+
+	for i, test := range tests {
+		builder := NewConfBuilder().Add(newServers(test.base...)).Add(newServers(test.upper...))
+		test.setup(builder)
+
+		root, err := builder.Build()
+		if err != nil {
+			t.Fatalf("can't build the configuration %d: %s", i, err.Error())
+		}
+
+		for _, key := range test.deleted {
+			if err := root.Delete(key); err != nil {
+				t.Fatalf("can't delete %s of the configuration %d: %s", key, i, err.Error())
+			}
+		}
+
+		servers := root.GetArraySection("servers")
+		names := make([]string, 0, servers.Length())
+
+		for idx := 0; idx < servers.Length(); idx++ {
+			names = append(names, servers.GetIndexSection(idx).TryGetString("name", ""))
+		}
+
+		if fmt.Sprint(names) != fmt.Sprint(test.expected) {
+			t.Errorf("array %d after the deletion, expected: %v, returned: %v", i, test.expected, names)
+		}
+	}
+}
+
+func expectRemoved(t *testing.T, changes chan ConfChanges, key string) {
+	select {
+	case c := <-changes:
+		for _, change := range c.GetChanges() {
+			if change.KeyName == key && change.Mode == Removed {
+				return
+			}
+		}
+
+		t.Errorf("changes of the deletion, expected: %s removed, returned: %v", key, c.GetChanges())
+	case <-time.After(time.Second):
+		t.Errorf("the deletion of %s wasn't notified", key)
+	}
+}
@@ -0,0 +1,124 @@
+package gconf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RedactedValue replaces the values of the secret keys in the dumps.
+const RedactedValue = "******"
+
+type dumpOptions struct {
+	redactedKeys []string
+	redactFuncs  []func(key string) bool
+}
+
+type DumpOption func(*dumpOptions)
+
+// WithRedactedKeys redacts the values of the keys and of every key under them.
+func WithRedactedKeys(keys ...string) DumpOption {
+	return func(o *dumpOptions) {
+		for _, k := range keys {
+			o.redactedKeys = append(o.redactedKeys, PathCombine(RootPath, k))
+		}
+	}
+}
+
+// WithRedactFunc redacts the values of the keys for which the function returns true,
+// the function receives the full path of the key.
+func WithRedactFunc(redact func(key string) bool) DumpOption {
+	return func(o *dumpOptions) {
+		if redact != nil {
+			o.redactFuncs = append(o.redactFuncs, redact)
+		}
+	}
+}
+
+func (o *dumpOptions) isRedacted(key string) bool {
+	for _, k := range o.redactedKeys {
+		if IsKeyInPath(k, key) {
+			return true
+		}
+	}
+
+	for _, redact := range o.redactFuncs {
+		if redact(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// registryHolder is implemented by the configurations which know the parser registry of their builder.
+type registryHolder interface {
+	getConfParserRegistry() *ConfParserRegistry
+}
+
+// confParserRegistryOf returns the parser registry of the configuration, or the global registry.
+func confParserRegistryOf(conf interface{}) *ConfParserRegistry {
+	if h, ok := conf.(registryHolder); ok {
+		if r := h.getConfParserRegistry(); r != nil {
+			return r
+		}
+	}
+
+	return defaultConfParserRegistry
+}
+
+// Dump writes the configuration under its path as a document of the format, the keys
+// are relative to the path and sorted. The format is any format of the parser registry of the builder
+// whose parser is also a ConfEncoder: json, yaml, toml and properties are built-in.
+func (t *TypeConverter) Dump(w io.Writer, format string, options ...DumpOption) error {
+	if w == nil {
+		return errors.New("[TypeConverter::Dump] invalid null argument: w")
+	}
+
+	parser, ok := confParserRegistryOf(t.confBase).GetParser(format)
+	if !ok {
+		return errors.New(fmt.Sprintf("[TypeConverter::Dump] unknown format: %s", format))
+	}
+
+	encoder, ok := parser.(ConfEncoder)
+	if !ok {
+		return errors.New(fmt.Sprintf("[TypeConverter::Dump] the parser of the format can't encode: %s", format))
+	}
+
+	opts := dumpOptions{}
+
+	for _, o := range options {
+		o(&opts)
+	}
+
+	path := PathCombine(t.confBase.GetPath())
+	data := make(map[string]interface{})
+
+	for _, p := range t.confBase.ToKeyValuePairs() {
+		key := PathCombine(p.Key)
+
+		if key == path || !IsKeyInPath(path, key) {
+			continue
+		}
+
+		value := p.Value
+		if opts.isRedacted(key) {
+			value = RedactedValue
+		}
+
+		if path == RootPath {
+			data[key] = value
+		} else {
+			data[PathCombine(RootPath, key[len(path):])] = value
+		}
+	}
+
+	stream, err := encoder.Encode(data)
+	if err != nil {
+		return errors.New(fmt.Sprintf("[TypeConverter::Dump] can't encode the configuration as %s: %s", format, err.Error()))
+	}
+
+	_, err = w.Write(stream)
+
+	return err
+}
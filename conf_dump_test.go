@@ -0,0 +1,168 @@
+package gconf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	source := NewMapConfSource(map[string]interface{}{
+		"database": map[string]interface{}{
+			"host":     "localhost",
+			"password": "secret",
+			"replicas": []interface{}{
+				map[string]interface{}{"host": "alpha", "port": 5432},
+				map[string]interface{}{"host": "beta", "port": 5433},
+			},
+		},
+		"logging": map[string]interface{}{"level": "info"},
+	})
+
+	root, err := NewConfBuilder().Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	tests := []struct {
+		conf     Conf
+		format   string
+		expected string
+	}{
+		{root.GetSection("logging"), "json", "{\n  \"level\": \"info\"\n}\n"},
+		{root.GetSection("logging"), "yaml", "level: info\n"},
+		{root.GetSection("logging"), "toml", "level = \"info\"\n"},
+		{root.GetArraySection("database/replicas"), "json", "[\n  {\n    \"host\": \"alpha\",\n    \"port\": 5432\n  },\n  {\n    \"host\": \"beta\",\n    \"port\": 5433\n  }\n]\n"},
+		{root.GetSection("database"), "properties",
+			"host=localhost\npassword=******\nreplicas[0].host=alpha\nreplicas[0].port=5432\nreplicas[1].host=beta\nreplicas[1].port=5433\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+
+		if err := test.conf.Dump(&buf, test.format, WithRedactedKeys("database/password")); err != nil {
+			t.Fatalf("can't dump the section[%s] as %s: %s", test.conf.GetPath(), test.format, err.Error())
+		}
+
+		if buf.String() != test.expected {
+			t.Errorf("dump of the section[%s] as %s, expected: %q, returned: %q", test.conf.GetPath(), test.format, test.expected, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+
+	err = root.Dump(&buf, "json", WithRedactFunc(func(key string) bool { return strings.HasSuffix(key, "/host") }))
+	if err != nil {
+		t.Fatalf("can't dump the root: %s", err.Error())
+	}
+
+	if strings.Contains(buf.String(), "alpha") || !strings.Contains(buf.String(), "secret") {
+		t.Errorf("dump redacted by the function, returned: %s", buf.String())
+	}
+
+	if err := root.GetArraySection("database/replicas").Dump(&buf, "toml"); err == nil {
+		t.Errorf("dump of an array as toml, expected: error, returned: nil")
+	}
+}
+
+func TestDumpTomlIntegers(t *testing.T) {
+	root, err := NewConfBuilder().Add(NewJsonConfSource([]byte(`{"port": 5432, "ratio": 0.5}`))).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	var buf bytes.Buffer
+
+	if err := root.Dump(&buf, "toml"); err != nil {
+		t.Fatalf("can't dump the configuration as toml: %s", err.Error())
+	}
+
+	expected := "port = 5432\nratio = 0.5\n"
+
+	if buf.String() != expected {
+		t.Errorf("dump of the json numbers as toml, expected: %q, returned: %q", expected, buf.String())
+	}
+}
+
+func TestPropertiesRoundTrip(t *testing.T) {
+	stream := []byte("# comment\nserver.name = my\\=app\nserver.ports[0]=80\nserver.ports[10]:443\nserver.ports[2]=8080\n")
+
+	data, err := propertiesFileFormat{}.Parse(stream)
+	if err != nil {
+		t.Fatalf("can't parse the properties: %s", err.Error())
+	}
+
+	if data["/server/name"] != "my=app" || data["/server/ports/$10"] != "443" {
+		t.Errorf("parsed properties, returned: %v", data)
+	}
+
+	encoded, err := propertiesFileFormat{}.Encode(data)
+	if err != nil {
+		t.Fatalf("can't encode the properties: %s", err.Error())
+	}
+
+	expected := "server.name=my=app\nserver.ports[0]=80\nserver.ports[2]=8080\nserver.ports[10]=443\n"
+
+	if string(encoded) != expected {
+		t.Errorf("encoded properties, expected: %q, returned: %q", expected, string(encoded))
+	}
+}
+
+type keysFileFormat struct{}
+
+func (keysFileFormat) Parse(stream []byte) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (keysFileFormat) Encode(data map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+
+	sortPaths(keys)
+
+	return []byte(strings.Join(keys, ",")), nil
+}
+
+func TestDumpBuilderFormat(t *testing.T) {
+	root, err := NewConfBuilder().
+		RegisterConfParser(keysFileFormat{}, "keys").
+		Add(NewMapConfSource(map[string]interface{}{
+			"logging": map[string]interface{}{"level": "info", "file": "app.log"},
+		})).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	tests := []struct {
+		conf     Conf
+		expected string
+	}{
+		{root, "/logging/file,/logging/level"},
+		{root.GetSection("logging"), "/file,/level"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+
+		if err := test.conf.Dump(&buf, "keys"); err != nil {
+			t.Fatalf("can't dump %s with the format of the builder: %s", test.conf.GetPath(), err.Error())
+		}
+
+		if buf.String() != test.expected {
+			t.Errorf("dump of %s, expected: %s, returned: %s", test.conf.GetPath(), test.expected, buf.String())
+		}
+	}
+
+	other, err := NewConfBuilder().Add(NewMapConfSource(map[string]interface{}{"x": 1})).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	if err := other.Dump(&bytes.Buffer{}, "keys"); err == nil {
+		t.Errorf("dump with the format of another builder, expected: an error, returned: nil")
+	}
+}
@@ -0,0 +1,119 @@
+package gconf
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// KeyOrigin describes a provider which defines a key.
+type KeyOrigin struct {
+	Key      string
+	Source   string
+	Value    interface{}
+	FilePath string
+	Line     int
+	Column   int
+	Winner   bool
+}
+
+func (o KeyOrigin) String() string {
+	winner := ""
+	if o.Winner {
+		winner = " (winner)"
+	}
+
+	if o.FilePath == "" {
+		return fmt.Sprintf("%s: %v from %s%s", o.Key, o.Value, o.Source, winner)
+	}
+
+	return fmt.Sprintf("%s: %v from %s[%s:%d:%d]%s", o.Key, o.Value, o.Source, o.FilePath, o.Line, o.Column, winner)
+}
+
+type sourceHolder interface {
+	getSource() ConfSource
+}
+
+// Explain lists the override and every provider which define the key in the order of precedence.
+// The winner is the first one whose value is the merged value returned by Get, there is no winner
+// when the key is dropped by the merge of the arrays.
+func (c *confRoot) Explain(key string) []KeyOrigin {
+	key = PathCombine(c.path, key)
+	merged, exist := c.getCombinedMap()[key]
+	won := false
+
+	var origins []KeyOrigin
+
+	if o, exist := c.overrides.get()[key]; exist {
+		origins = append(origins, KeyOrigin{
+			Key:    key,
+			Source: fmt.Sprintf("override set by %s at %s", o.SetBy, o.SetAt.Format(time.RFC3339)),
+			Value:  o.Value,
+			Winner: true,
+		})
+
+		won = true
+	}
+
+	for _, p := range c.providers {
+		if !p.ContainKey(key) {
+			continue
+		}
+
+		origin := KeyOrigin{
+			Key:    key,
+			Source: describeProvider(p),
+			Value:  p.Get(key),
+		}
+
+		if exist && !won && reflect.DeepEqual(origin.Value, merged) {
+			origin.Winner = true
+			won = true
+		}
+
+		if h, ok := p.(sourceHolder); ok {
+			locateOrigin(&origin, h.getSource())
+		}
+
+		origins = append(origins, origin)
+	}
+
+	return origins
+}
+
+func locateOrigin(origin *KeyOrigin, source ConfSource) {
+	if fs, ok := source.(FileConfSource); ok {
+		origin.FilePath = fs.GetFilePath()
+	}
+
+	locator, ok := source.(KeyLocator)
+	if !ok {
+		return
+	}
+
+	if pos, err := locator.LocateKey(origin.Key); err == nil {
+		origin.Line = pos.Line
+		origin.Column = pos.Column
+	}
+}
+
+func describeProvider(p ConfProvider) string {
+	if h, ok := p.(sourceHolder); ok && h.getSource() != nil {
+		return describeType(h.getSource())
+	}
+
+	return describeType(p)
+}
+
+func describeType(v interface{}) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Name()
+}
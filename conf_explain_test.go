@@ -0,0 +1,147 @@
+package gconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func getProviders(root ConfRoot) []ConfProvider {
+	switch r := root.(type) {
+	case interface {
+		GetProviders() []ConfProvider
+	}:
+		return r.GetProviders()
+	case ConfProvider:
+		return []ConfProvider{r}
+	}
+
+	return nil
+}
+
+func reloadProviders(root ConfRoot) error {
+	for _, p := range getProviders(root) {
+		if err := p.Reload(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestExplain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "base.json")
+	prod := filepath.Join(dir, "prod.yml")
+
+	files := map[string]string{
+		base: "{\n  \"logging\": {\n    \"printLevel\": \"debug\",\n    \"servers\": [\"a\", \"b\"]\n  }\n}\n",
+		prod: "# production\nlogging:\n  printLevel: error\n",
+	}
+
+	for path, content := range files {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("can't write the file[%s]: %s", path, err.Error())
+		}
+	}
+
+	root, err := NewConfBuilder().
+		Add(NewFileConfSource(base)).
+		Add(NewFileConfSource(prod)).
+		Add(NewMapConfSource(map[string]interface{}{"other": 1})).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	origins := root.Explain("logging/printLevel")
+
+	if len(origins) != 2 {
+		t.Fatalf("origins of the key, expected: 2, returned: %v", origins)
+	}
+
+	expected := []KeyOrigin{
+		{Key: "/logging/printLevel", Value: "error", FilePath: prod, Line: 3, Column: 3, Winner: true},
+		{Key: "/logging/printLevel", Value: "debug", FilePath: base, Line: 3, Column: 5},
+	}
+
+	for i, e := range expected {
+		o := origins[i]
+		o.Source = ""
+
+		if o != e {
+			t.Errorf("origin %d of the key, expected: %s, returned: %s", i, e.String(), o.String())
+		}
+	}
+
+	if origins := root.Explain("logging/servers/$1"); len(origins) != 1 || origins[0].Line != 4 || origins[0].Column != 22 {
+		t.Errorf("origin of the array element, expected: line 4 column 22, returned: %v", origins)
+	}
+
+	if origins := root.Explain("other"); len(origins) != 1 || !origins[0].Winner || origins[0].FilePath != "" || origins[0].Line != 0 {
+		t.Errorf("origin of the key of the memory, returned: %v", origins)
+	}
+
+	// the positions describe the loaded contents until the file is reloaded
+	if err := ioutil.WriteFile(prod, []byte("# production\n\n\nlogging:\n  printLevel: error\n"), 0644); err != nil {
+		t.Fatalf("can't write the file[%s]: %s", prod, err.Error())
+	}
+
+	if o := root.Explain("logging/printLevel")[0]; o.Line != 3 {
+		t.Errorf("line of the key before the reload, expected: 3, returned: %d", o.Line)
+	}
+
+	if err := ioutil.WriteFile(prod, []byte("logging: [invalid\n"), 0644); err != nil {
+		t.Fatalf("can't write the file[%s]: %s", prod, err.Error())
+	}
+
+	if err := reloadProviders(root); err == nil {
+		t.Errorf("reload of the invalid file, expected: an error, returned: nil")
+	}
+
+	if o := root.Explain("logging/printLevel")[0]; o.Line != 3 || o.Value != "error" {
+		t.Errorf("origin after the rejected reload, expected: line 3, returned: %s", o.String())
+	}
+
+	if err := ioutil.WriteFile(prod, []byte("# production\n\n\nlogging:\n  printLevel: warn\n"), 0644); err != nil {
+		t.Fatalf("can't write the file[%s]: %s", prod, err.Error())
+	}
+
+	if err := reloadProviders(root); err != nil {
+		t.Fatalf("can't reload the configuration: %s", err.Error())
+	}
+
+	if o := root.Explain("logging/printLevel")[0]; o.Line != 5 || o.Value != "warn" {
+		t.Errorf("origin after the reload, expected: line 5, returned: %s", o.String())
+	}
+}
+
+func TestScanTomlPositions(t *testing.T) {
+	positions, err := scanTomlPositions([]byte("title = \"x\"\n\n[database]\n  host = \"db\"\n\n[[servers]]\nname = \"a\"\n\n[[servers]]\nname = \"b\"\n"))
+	if err != nil {
+		t.Fatalf("can't scan the positions: %s", err.Error())
+	}
+
+	expected := map[string]KeyPosition{
+		"/title":           {1, 1},
+		"/database/host":   {4, 3},
+		"/servers/$1/name": {10, 1},
+	}
+
+	for key, e := range expected {
+		if pos, ok := locateKey(positions, key); !ok || pos != e {
+			t.Errorf("position of the key[%s], expected: %v, returned: %v", key, e, pos)
+		}
+	}
+
+	if pos, ok := locateKey(positions, "/database/host/missing"); !ok || pos != (KeyPosition{4, 3}) {
+		t.Errorf("position of the undeclared key, expected: the position of its parent, returned: %v", pos)
+	}
+}
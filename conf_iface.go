@@ -1,5 +1,10 @@
 package gconf
 
+import (
+	"context"
+	"io"
+)
+
 type KeyValuePair struct {
 	Key   string
 	Value interface{}
@@ -9,6 +14,8 @@ type ConfBase interface {
 	GetPath() string
 	Get(key string) interface{}
 	Set(key string, value interface{}) error
+	Delete(key string) error
+	DeleteSection(key string) error
 	ContainKey(key string) bool
 	Keys() []string
 	Values() []interface{}
@@ -30,6 +37,7 @@ type Conf interface {
 	GetComplex64(key string) (complex64, error)
 	GetComplex128(key string) (complex128, error)
 	GetString(key string) (string, error)
+	GetValue(key string) (interface{}, error)
 	TryGet(key string, defaultValue interface{}) interface{}
 	TryGetBoolean(key string, defaultValue bool) bool
 	TryGetByte(key string, defaultValue byte) byte
@@ -44,27 +52,50 @@ type Conf interface {
 	TryGetString(key string, defaultValue string) string
 	GetSection(key string) ConfSection
 	GetArraySection(key string) ConfArraySection
-	//Unmarshal(key string, out interface{}) error  // to be supported in the future
+	Unmarshal(key string, out interface{}) error
+	Dump(w io.Writer, format string, options ...DumpOption) error
+	Query(pattern string) ([]KeyValuePair, error)
 }
 
 type ConfBuilder interface {
 	Add(confSource ConfSource) ConfBuilder
 	GetSources() []ConfSource
+	RegisterConfParser(parser ConfParser, formats ...string) ConfBuilder
+	GetConfParserRegistry() *ConfParserRegistry
+	AddValidator(validator ConfValidator) ConfBuilder
+	SetArrayMergePolicy(path string, policy ArrayMergePolicy) ConfBuilder
+	SetArrayMergeKey(path string, keyField string) ConfBuilder
 	Build() (ConfRoot, error)
 }
 
 type ConfRoot interface {
 	Conf
+	GetChildren() []ConfSection
+	Explain(key string) []KeyOrigin
+	OnChange(prefix string, callback func(ConfChanges)) ChangeTokenRegistration
+	Watch(ctx context.Context, prefix string, options ...WatchOption) <-chan ConfChanges
+	OnReloadFailed(callback func(err error))
+	Rollback() error
+	SetOverride(key string, value interface{}, setBy string) error
+	ClearOverride(key string) error
+	ListOverrides() []Override
+	GetChangeToken() ChangeToken
 	Reload() error
 	Dispose()
 }
 
 type ConfSection interface {
 	Conf
+	Key() string
+	Value() interface{}
+	GetChildren() []ConfSection
 }
 
 type ConfArraySection interface {
 	Conf
+	Key() string
+	Value() interface{}
+	GetChildren() []ConfSection
 	Length() int
 	GetIndexSection(idx int) ConfSection
 }
@@ -82,6 +113,12 @@ type ConfProvider interface {
 	GetChangeToken() ChangeToken
 }
 
+// ConfChangeNotifier is implemented by the providers which notify the changes of their contents,
+// the root subscribes to the providers which implement it.
+type ConfChangeNotifier interface {
+	AddOnConfChangedCallback(callback func(ConfChanges))
+}
+
 type ConfSource interface {
 	Build(confBuilder ConfBuilder) (ConfProvider, error)
 	Load() (map[string]interface{}, error)
@@ -95,6 +132,7 @@ type Watcher interface {
 
 type ChangeToken interface {
 	SetCallback(callback func())
+	RegisterChangeCallback(callback func()) ChangeTokenRegistration
 	HasChanged() bool
 	SetAsChanged()
 	OnChanged()
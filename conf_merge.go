@@ -0,0 +1,374 @@
+package gconf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ArrayMergePolicy decides how an array of a provider is merged with the same array
+// of the providers of the lower priority.
+type ArrayMergePolicy int
+
+const (
+	// ArrayReplace replaces the whole array, it is the default policy.
+	ArrayReplace ArrayMergePolicy = iota
+	// ArrayMergeByIndex merges the elements of the same index.
+	ArrayMergeByIndex
+	// ArrayAppend appends the elements after the ones of the lower priority.
+	ArrayAppend
+	// ArrayMergeByKey merges the elements whose key fields are equal and appends the others.
+	ArrayMergeByKey
+)
+
+const anyPathEntity = "*"
+
+// keyCaseFollower is implemented by the sources whose keys are spelled the way the providers
+// of the lower priority spell them when they are merged, such as the environment variables.
+type keyCaseFollower interface {
+	followsKeyCase() bool
+}
+
+type arrayMergeRule struct {
+	path     string
+	entities []string
+	policy   ArrayMergePolicy
+	keyField string
+}
+
+type arrayMergeRules []arrayMergeRule
+
+// confOrigin is a key of a provider which was merged into a node of the merged tree.
+type confOrigin struct {
+	provider ConfProvider
+	key      string
+}
+
+type mergeLayer struct {
+	provider ConfProvider
+	pairs    []KeyValuePair
+}
+
+func newArrayMergeRule(path string, policy ArrayMergePolicy, keyField string) arrayMergeRule {
+	path = PathCombine(RootPath, path)
+
+	return arrayMergeRule{
+		path:     path,
+		entities: NewStringSplitter(path).Split(PathDelimiter, true),
+		policy:   policy,
+		keyField: keyField,
+	}
+}
+
+func (r arrayMergeRule) validate() error {
+	if r.policy == ArrayMergeByKey && r.keyField == "" {
+		return errors.New(fmt.Sprintf("the array[%s] is merged by key but its key field is empty", r.path))
+	}
+
+	if r.policy < ArrayReplace || r.policy > ArrayMergeByKey {
+		return errors.New(fmt.Sprintf("unknown merge policy of the array[%s]: %d", r.path, r.policy))
+	}
+
+	return nil
+}
+
+func (r arrayMergeRule) match(entities []string) bool {
+	if len(entities) != len(r.entities) {
+		return false
+	}
+
+	for i, e := range r.entities {
+		if e != anyPathEntity && !strings.EqualFold(e, entities[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// find returns the rule of the array path, the rule added last wins.
+func (rules arrayMergeRules) find(path []string) arrayMergeRule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].match(path) {
+			return rules[i]
+		}
+	}
+
+	return arrayMergeRule{policy: ArrayReplace}
+}
+
+// mergeProviders merges the pairs of the providers, which are ordered from the highest priority
+// to the lowest one, into a flattened map.
+func (rules arrayMergeRules) mergeProviders(providers []ConfProvider) map[string]interface{} {
+	layers := getMergeLayers(providers)
+	data := make(map[string]interface{})
+
+	// a single provider has nothing to merge
+	if len(layers) == 1 {
+		for _, p := range layers[0].pairs {
+			if p.Value != nil {
+				data[p.Key] = p.Value
+			}
+		}
+
+		return data
+	}
+
+	if merged := rules.mergeLayers(layers); merged != nil {
+		merged.flatten(RootPath, data)
+	}
+
+	return data
+}
+
+// findOrigins returns the keys of the providers which were merged into the key, the keys of
+// an array are ordered from the last element to the first one, since the providers shift the
+// elements which follow a deleted one.
+func (rules arrayMergeRules) findOrigins(providers []ConfProvider, key string) []confOrigin {
+	node := rules.mergeLayers(getMergeLayers(providers))
+
+	for _, e := range NewStringSplitter(key).Split(PathDelimiter, true) {
+		if node == nil {
+			return nil
+		}
+
+		node = node.child(e)
+	}
+
+	if node == nil {
+		return nil
+	}
+
+	origins := append([]confOrigin(nil), node.origins...)
+
+	sort.SliceStable(origins, func(i, j int) bool {
+		return compareKeys(origins[j].key, origins[i].key) < 0
+	})
+
+	return origins
+}
+
+// getMergeLayers returns the providers which have pairs, from the lowest priority to the highest one.
+func getMergeLayers(providers []ConfProvider) []mergeLayer {
+	var layers []mergeLayer
+
+	for i := len(providers) - 1; i >= 0; i-- {
+		if pairs := providers[i].ToKeyValuePairs(); len(pairs) != 0 {
+			layers = append(layers, mergeLayer{provider: providers[i], pairs: pairs})
+		}
+	}
+
+	return layers
+}
+
+// mergeLayers merges the trees of the layers, every node keeps the keys of the providers
+// it was merged from.
+func (rules arrayMergeRules) mergeLayers(layers []mergeLayer) *confNode {
+	var merged *confNode
+
+	for _, l := range layers {
+		tree := buildConfTree(RootPath, l.pairs)
+		tree.setOrigin(l.provider)
+
+		if merged == nil {
+			merged = tree
+			continue
+		}
+
+		if followsKeyCase(l.provider) {
+			adoptKeyCase(merged, tree)
+		}
+
+		rules.mergeNode(merged, tree, nil)
+	}
+
+	return merged
+}
+
+// mergeNode merges src into dst, the entities are the path of dst. A scalar of src shadows
+// the whole subtree of dst and a section of src shadows the scalar of dst. The null scalars
+// are kept as tombstones, which remove the key when the tree is flattened.
+func (rules arrayMergeRules) mergeNode(dst *confNode, src *confNode, entities []string) {
+	dst.value = src.value
+	dst.hasValue = src.hasValue
+	dst.origins = append(dst.origins, src.origins...)
+
+	if !src.hasChildren() {
+		dst.clearChildren()
+		return
+	}
+
+	if !src.isArray() {
+		rules.mergeChildren(dst, src.children, entities)
+		return
+	}
+
+	rule := rules.find(entities)
+
+	switch {
+	case rule.policy == ArrayMergeByIndex:
+		rules.mergeChildren(dst, src.children, entities)
+	case rule.policy == ArrayAppend && dst.isArray():
+		length := dst.arrayLength()
+
+		for _, c := range src.sortedChildren() {
+			idx, _ := ParseArrayIndex(c.name)
+			dst.setChild(c.copyAs(GetArrayIndex(length + idx)))
+		}
+	case rule.policy == ArrayMergeByKey && dst.isArray():
+		rules.mergeByKey(dst, src, entities, rule.keyField)
+	default:
+		dst.clearChildren()
+
+		for _, c := range src.children {
+			dst.setChild(c.copyAs(c.name))
+		}
+	}
+}
+
+func (rules arrayMergeRules) mergeChildren(dst *confNode, children []*confNode, entities []string) {
+	for _, c := range children {
+		child := dst.child(c.name)
+
+		if child == nil {
+			dst.setChild(c.copyAs(c.name))
+			continue
+		}
+
+		// the name of the existing node is kept when they differ in case, so the keys
+		// of the providers of the lower priority can still be read as they are spelled
+		rules.mergeNode(child, c, append(entities[:len(entities):len(entities)], c.name))
+	}
+}
+
+// mergeByKey merges the elements of src into the elements of dst which have the same key field,
+// the elements without a match are appended.
+func (rules arrayMergeRules) mergeByKey(dst *confNode, src *confNode, entities []string, keyField string) {
+	length := dst.arrayLength()
+
+	for _, c := range src.sortedChildren() {
+		if target := findElementByKey(dst, keyField, c); target != nil {
+			rules.mergeNode(target, c, append(entities[:len(entities):len(entities)], target.name))
+			continue
+		}
+
+		dst.setChild(c.copyAs(GetArrayIndex(length)))
+		length++
+	}
+}
+
+func findElementByKey(array *confNode, keyField string, element *confNode) *confNode {
+	key := element.child(keyField)
+
+	if key == nil || !key.hasValue {
+		return nil
+	}
+
+	for _, c := range array.children {
+		if k := c.child(keyField); k != nil && k.hasValue && reflect.DeepEqual(k.value, key.value) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func followsKeyCase(p ConfProvider) bool {
+	h, ok := p.(sourceHolder)
+	if !ok {
+		return false
+	}
+
+	f, ok := h.getSource().(keyCaseFollower)
+
+	return ok && f.followsKeyCase()
+}
+
+// adoptKeyCase renames the nodes of src which are in dst case-insensitively to the names of dst.
+func adoptKeyCase(dst *confNode, src *confNode) {
+	for _, c := range src.children {
+		if d := dst.child(c.name); d != nil {
+			c.name = d.name
+			adoptKeyCase(d, c)
+		}
+	}
+}
+
+func (n *confNode) setChild(child *confNode) {
+	name := strings.ToLower(child.name)
+
+	if prev, exist := n.index[name]; exist {
+		for i, c := range n.children {
+			if c == prev {
+				n.children[i] = child
+				break
+			}
+		}
+	} else {
+		n.children = append(n.children, child)
+	}
+
+	n.index[name] = child
+}
+
+func (n *confNode) clearChildren() {
+	n.children = nil
+	n.index = make(map[string]*confNode)
+}
+
+// copyAs returns a deep copy of the node with the name, the paths of the copies aren't kept
+// since the merged tree is flattened from the names.
+func (n *confNode) copyAs(name string) *confNode {
+	c := newConfNode(name, "")
+	c.value = n.value
+	c.hasValue = n.hasValue
+	c.origins = append([]confOrigin(nil), n.origins...)
+
+	for _, child := range n.children {
+		c.setChild(child.copyAs(child.name))
+	}
+
+	return c
+}
+
+// setOrigin sets the provider as the origin of the node and its descendants.
+func (n *confNode) setOrigin(provider ConfProvider) {
+	n.origins = []confOrigin{{provider: provider, key: n.path}}
+
+	for _, c := range n.children {
+		c.setOrigin(provider)
+	}
+}
+
+// compareKeys compares the keys entity by entity, the array indexes by their number.
+func compareKeys(a string, b string) int {
+	ae := NewStringSplitter(a).Split(PathDelimiter, true)
+	be := NewStringSplitter(b).Split(PathDelimiter, true)
+
+	for i := 0; i < len(ae) && i < len(be); i++ {
+		ai, aok := ParseArrayIndex(ae[i])
+		bi, bok := ParseArrayIndex(be[i])
+
+		switch {
+		case aok && bok && ai != bi:
+			return ai - bi
+		case !(aok && bok) && ae[i] != be[i]:
+			return strings.Compare(ae[i], be[i])
+		}
+	}
+
+	return len(ae) - len(be)
+}
+
+// flatten writes the values of the node and its descendants into the data, the tombstones are skipped.
+func (n *confNode) flatten(path string, data map[string]interface{}) {
+	if n.hasValue && n.value != nil {
+		data[path] = n.value
+	}
+
+	for _, c := range n.children {
+		c.flatten(PathCombine(path, c.name), data)
+	}
+}
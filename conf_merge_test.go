@@ -0,0 +1,97 @@
+package gconf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestArrayMergePolicies(t *testing.T) {
+	base := []byte(`{ "servers": [
+		{ "name": "alpha", "port": 1 },
+		{ "name": "beta", "port": 2 },
+		{ "name": "gamma", "port": 3 }
+	] }`)
+
+	prod := []byte(`{ "servers": [
+		{ "name": "gamma", "port": 30 },
+		{ "name": "delta", "port": 40 }
+	] }`)
+
+	tests := []struct {
+		setup    func(ConfBuilder)
+		expected []string
+	}{
+		{func(ConfBuilder) {}, []string{"gamma:30", "delta:40"}},
+		{func(b ConfBuilder) { b.SetArrayMergePolicy("servers", ArrayMergeByIndex) }, []string{"gamma:30", "delta:40", "gamma:3"}},
+		{func(b ConfBuilder) { b.SetArrayMergePolicy("servers", ArrayAppend) }, []string{"alpha:1", "beta:2", "gamma:3", "gamma:30", "delta:40"}},
+		{func(b ConfBuilder) { b.SetArrayMergeKey("servers", "name") }, []string{"alpha:1", "beta:2", "gamma:30", "delta:40"}},
+	}
+
+	for i, test := range tests {
+		builder := NewConfBuilder().Add(NewJsonConfSource(base)).Add(NewJsonConfSource(prod))
+		test.setup(builder)
+
+		root, err := builder.Build()
+		if err != nil {
+			t.Fatalf("can't build the configuration %d: %s", i, err.Error())
+		}
+
+		servers := root.GetArraySection("servers")
+
+		if servers.Length() != len(test.expected) {
+			t.Errorf("length of the array %d, expected: %d, returned: %d", i, len(test.expected), servers.Length())
+			continue
+		}
+
+		for idx, e := range test.expected {
+			server := servers.GetIndexSection(idx)
+
+			if s := server.TryGetString("name", "") + ":" + server.TryGetString("port", ""); s != e {
+				t.Errorf("element %d of the array %d, expected: %s, returned: %s", idx, i, e, s)
+			}
+		}
+	}
+
+	root, err := NewConfBuilder().Add(NewJsonConfSource(base)).Add(NewJsonConfSource(prod)).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	var buf bytes.Buffer
+
+	if err := root.GetArraySection("servers").Dump(&buf, "properties"); err != nil {
+		t.Fatalf("can't dump the array: %s", err.Error())
+	}
+
+	if expected := "[0].name=gamma\n[0].port=30\n[1].name=delta\n[1].port=40\n"; buf.String() != expected {
+		t.Errorf("dump of the replaced array, expected: %q, returned: %q", expected, buf.String())
+	}
+
+	for _, origin := range root.Explain("servers/$2/name") {
+		if origin.Winner {
+			t.Errorf("winner of the key dropped by the merge, expected: none, returned: %s", origin.String())
+		}
+	}
+
+	if _, err := NewConfBuilder().SetArrayMergePolicy("servers", ArrayMergeByKey).Build(); err == nil {
+		t.Errorf("merge by key without the key field, expected: an error, returned: nil")
+	}
+}
+
+func TestMergeKeyCase(t *testing.T) {
+	base := []byte(`{ "logging": { "printLevel": "debug", "file": "a.log" } }`)
+	upper := []byte(`{ "Logging": { "PrintLevel": "info" } }`)
+
+	root, err := NewConfBuilder().Add(NewJsonConfSource(base)).Add(NewJsonConfSource(upper)).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	if file := root.Get("logging/file"); file != "a.log" {
+		t.Errorf("key of the lower provider, expected: a.log, returned: %v", file)
+	}
+
+	if level := root.Get("logging/printLevel"); level != "info" {
+		t.Errorf("key merged case-insensitively, expected: info, returned: %v", level)
+	}
+}
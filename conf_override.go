@@ -0,0 +1,227 @@
+package gconf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Override is a value set at runtime which takes precedence over every provider.
+type Override struct {
+	Key   string
+	Value interface{}
+	SetBy string
+	SetAt time.Time
+}
+
+func (o Override) String() string {
+	return fmt.Sprintf("%s: %v set by %s at %s", o.Key, o.Value, o.SetBy, o.SetAt.Format(time.RFC3339))
+}
+
+// overrideLayer keeps the overrides of the root apart from the providers, so the reloads
+// of the sources don't wipe them. The map of the overrides is never modified once it is stored.
+type overrideLayer struct {
+	overrides   map[string]Override
+	version     uint64
+	changeToken ChangeToken
+	mutex       sync.RWMutex
+	writeMutex  sync.Mutex
+}
+
+func newOverrideLayer(overrides map[string]Override) *overrideLayer {
+	if overrides == nil {
+		overrides = make(map[string]Override)
+	}
+
+	return &overrideLayer{
+		overrides:   overrides,
+		changeToken: NewChangeToken(),
+	}
+}
+
+func (o *overrideLayer) get() map[string]Override {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	return o.overrides
+}
+
+func (o *overrideLayer) getVersion() uint64 {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	return o.version
+}
+
+func (o *overrideLayer) GetChangeToken() ChangeToken {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	return o.changeToken
+}
+
+func (o *overrideLayer) swap(overrides map[string]Override) {
+	o.mutex.Lock()
+	o.overrides = overrides
+	o.version++
+	token := o.changeToken
+	o.changeToken = NewChangeToken()
+	o.mutex.Unlock()
+
+	token.OnChanged()
+}
+
+// inOrder returns the overrides in the order they were set.
+func (o *overrideLayer) inOrder() []Override {
+	overrides := o.get()
+	list := make([]Override, 0, len(overrides))
+
+	for _, v := range overrides {
+		list = append(list, v)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].SetAt.Equal(list[j].SetAt) {
+			return list[i].Key < list[j].Key
+		}
+
+		return list[i].SetAt.Before(list[j].SetAt)
+	})
+
+	return list
+}
+
+// shadowKey removes the key along with the keys under it and the values of its parents,
+// so the value set on the key doesn't mix with the sections of a different shape.
+func shadowKey(data map[string]interface{}, key string) {
+	for k := range data {
+		if IsKeyInPath(key, k) || IsKeyInPath(k, key) {
+			delete(data, k)
+		}
+	}
+}
+
+func copyOverrides(overrides map[string]Override) map[string]Override {
+	c := make(map[string]Override, len(overrides)+1)
+
+	for k, v := range overrides {
+		c[k] = v
+	}
+
+	return c
+}
+
+// SetOverride sets the value of the key in the override layer, which takes precedence over
+// every provider and is kept across the reloads of the sources. setBy records who set the override.
+func (c *confRoot) SetOverride(key string, value interface{}, setBy string) error {
+	key = PathCombine(c.path, key)
+
+	if key == RootPath {
+		return errors.New("[confRoot::SetOverride] invalid argument: the key of the root can't be overridden")
+	}
+
+	return c.updateOverrides(func(overrides map[string]Override) error {
+		overrides[key] = Override{
+			Key:   key,
+			Value: value,
+			SetBy: setBy,
+			SetAt: time.Now(),
+		}
+
+		return nil
+	})
+}
+
+// ClearOverride removes the override of the key, the value of the providers becomes visible again.
+func (c *confRoot) ClearOverride(key string) error {
+	key = PathCombine(c.path, key)
+
+	return c.updateOverrides(func(overrides map[string]Override) error {
+		if _, exist := overrides[key]; !exist {
+			return errors.New(fmt.Sprintf("[confRoot::ClearOverride] there is no override of the key: %s", key))
+		}
+
+		delete(overrides, key)
+		return nil
+	})
+}
+
+// updateOverrides applies the update to a copy of the overrides, which replaces them when it is valid.
+// The subscribers are notified after the lock is released, so their callbacks can change the overrides too.
+func (c *confRoot) updateOverrides(update func(overrides map[string]Override) error) error {
+	c.overrides.writeMutex.Lock()
+
+	overrides := copyOverrides(c.overrides.get())
+
+	if err := update(overrides); err != nil {
+		c.overrides.writeMutex.Unlock()
+		return err
+	}
+
+	if err := c.validateOverrides(overrides); err != nil {
+		c.overrides.writeMutex.Unlock()
+		return err
+	}
+
+	c.overrides.swap(overrides)
+	c.overrides.writeMutex.Unlock()
+
+	c.notifyChanges()
+	return nil
+}
+
+// clearOverrides removes the override of the key, or every override under the key when section is set.
+func (c *confRoot) clearOverrides(key string, section bool) bool {
+	c.overrides.writeMutex.Lock()
+	defer c.overrides.writeMutex.Unlock()
+
+	overrides := copyOverrides(c.overrides.get())
+	cleared := false
+
+	for k := range overrides {
+		if k == key || (section && IsKeyInPath(key, k)) {
+			delete(overrides, k)
+			cleared = true
+		}
+	}
+
+	if cleared {
+		c.overrides.swap(overrides)
+	}
+
+	return cleared
+}
+
+// ListOverrides returns the overrides sorted by their keys.
+func (c *confRoot) ListOverrides() []Override {
+	overrides := c.overrides.get()
+	list := make([]Override, 0, len(overrides))
+
+	for _, o := range overrides {
+		list = append(list, o)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+
+	return list
+}
+
+// validateOverrides runs the validators of the root on the merged view with the overrides.
+func (c *confRoot) validateOverrides(overrides map[string]Override) error {
+	if len(c.validators) == 0 {
+		return nil
+	}
+
+	candidate := c.newCandidate(c.providers)
+	candidate.overrides = newOverrideLayer(overrides)
+
+	if err := validateConf(candidate, c.validators); err != nil {
+		return &validationError{err: err}
+	}
+
+	return nil
+}
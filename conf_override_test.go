@@ -0,0 +1,85 @@
+package gconf
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRootOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"level": "info", "version": "1"}`)
+
+	root, err := NewConfBuilder().
+		Add(NewMapConfSource(map[string]interface{}{"level": "warn"})).
+		Add(NewFileConfSource(path)).
+		AddValidator(func(conf Conf) error {
+			if conf.TryGetString("level", "") == "" {
+				return errors.New("the level is required")
+			}
+			return nil
+		}).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+	defer root.Dispose()
+
+	changes := make(chan ConfChanges, 10)
+	root.OnChange("level", func(c ConfChanges) { changes <- c })
+
+	before := time.Now()
+
+	if err := root.SetOverride("level", "debug", "ops"); err != nil {
+		t.Fatalf("can't set the override: %s", err.Error())
+	}
+
+	if err := root.SetOverride("level", "", "ops"); err == nil {
+		t.Errorf("override rejected by the validator, expected: an error, returned: nil")
+	}
+
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Errorf("the override wasn't notified")
+	}
+
+	writeFileAtomic(t, path, `{"level": "error", "version": "2"}`)
+	waitForValue(t, root, "version", "2")
+
+	if level := root.TryGetString("level", ""); level != "debug" {
+		t.Errorf("override after the reload, expected: %s, returned: %s", "debug", level)
+	}
+
+	overrides := root.ListOverrides()
+
+	if len(overrides) != 1 || overrides[0].Key != "/level" || overrides[0].SetBy != "ops" || overrides[0].SetAt.Before(before) {
+		t.Errorf("list of the overrides, returned: %v", overrides)
+	}
+
+	if origins := root.Explain("level"); len(origins) != 3 || !origins[0].Winner || origins[0].Value != "debug" {
+		t.Errorf("origins of the overridden key, returned: %v", origins)
+	}
+
+	if err := root.ClearOverride("level"); err != nil {
+		t.Fatalf("can't clear the override: %s", err.Error())
+	}
+
+	if err := root.ClearOverride("level"); err == nil {
+		t.Errorf("clearing a missing override, expected: an error, returned: nil")
+	}
+
+	if level := root.TryGetString("level", ""); level != "error" {
+		t.Errorf("value after clearing the override, expected: %s, returned: %s", "error", level)
+	}
+}
@@ -0,0 +1,242 @@
+package gconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfParser parses the contents of a configuration file into the flattened key/value map.
+type ConfParser interface {
+	Parse(stream []byte) (map[string]interface{}, error)
+}
+
+// ConfEncoder is implemented by the parsers which can also write their format,
+// it encodes the flattened key/value map back into the nested document.
+type ConfEncoder interface {
+	Encode(data map[string]interface{}) ([]byte, error)
+}
+
+// ConfPatcher is implemented by the encoders which can update the original document in place,
+// so the comments and the layout of the keys which didn't change are kept.
+type ConfPatcher interface {
+	Patch(original []byte, data map[string]interface{}) ([]byte, error)
+}
+
+// ConfParserFunc adapts a function to the ConfParser interface.
+type ConfParserFunc func(stream []byte) (map[string]interface{}, error)
+
+func (f ConfParserFunc) Parse(stream []byte) (map[string]interface{}, error) {
+	return f(stream)
+}
+
+// ConfParserRegistry finds the parsers by the file extensions and the MIME types.
+// A registry falls back to its parent when it can't find a parser, so a builder-scoped
+// registry can add or override the formats of the global one.
+type ConfParserRegistry struct {
+	parent  *ConfParserRegistry
+	parsers map[string]ConfParser
+	mutex   sync.RWMutex
+}
+
+var defaultConfParserRegistry = newDefaultConfParserRegistry()
+
+func NewConfParserRegistry(parent *ConfParserRegistry) *ConfParserRegistry {
+	return &ConfParserRegistry{
+		parent:  parent,
+		parsers: make(map[string]ConfParser),
+	}
+}
+
+func newDefaultConfParserRegistry() *ConfParserRegistry {
+	r := NewConfParserRegistry(nil)
+
+	r.Register(jsonFileFormat{}, "json", "application/json", "text/json")
+	r.Register(yamlFileFormat{}, "yaml", "yml", "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml")
+	r.Register(tomlFileFormat{}, "toml", "tml", "application/toml")
+	r.Register(propertiesFileFormat{}, "properties", "text/x-java-properties")
+
+	return r
+}
+
+// GetConfParserRegistry returns the global registry.
+func GetConfParserRegistry() *ConfParserRegistry {
+	return defaultConfParserRegistry
+}
+
+// RegisterConfParser registers the parser in the global registry.
+func RegisterConfParser(parser ConfParser, formats ...string) {
+	defaultConfParserRegistry.Register(parser, formats...)
+}
+
+// Register registers the parser for the formats, which are either file extensions
+// such as "json" or ".json", or MIME types such as "application/json".
+func (r *ConfParserRegistry) Register(parser ConfParser, formats ...string) *ConfParserRegistry {
+	if parser == nil {
+		return r
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, f := range formats {
+		if f = normalizeFormat(f); f != "" {
+			r.parsers[f] = parser
+		}
+	}
+
+	return r
+}
+
+// GetParser returns the parser of the file extension or the MIME type.
+func (r *ConfParserRegistry) GetParser(format string) (ConfParser, bool) {
+	format = normalizeFormat(format)
+
+	r.mutex.RLock()
+	parser, ok := r.parsers[format]
+	r.mutex.RUnlock()
+
+	if ok {
+		return parser, true
+	}
+
+	if r.parent != nil {
+		return r.parent.GetParser(format)
+	}
+
+	return nil, false
+}
+
+// GetParserByPath returns the parser of the extension of the file path.
+func (r *ConfParserRegistry) GetParserByPath(path string) (ConfParser, bool) {
+	return r.GetParser(filepath.Ext(path))
+}
+
+func normalizeFormat(format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+
+	if idx := strings.Index(format, ";"); idx != -1 {
+		format = strings.TrimSpace(format[:idx])
+	}
+
+	return strings.TrimPrefix(format, ".")
+}
+
+type jsonFileFormat struct{}
+
+func (jsonFileFormat) Parse(stream []byte) (map[string]interface{}, error) {
+	parser := newJsonConfParser(RootPath, PathDelimiter)
+
+	if err := parser.Parse(stream); err != nil {
+		return nil, err
+	}
+
+	return parser.GetDataMap(), nil
+}
+
+func (jsonFileFormat) Encode(data map[string]interface{}) ([]byte, error) {
+	stream, err := json.MarshalIndent(unflattenValue(data), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(stream, '\n'), nil
+}
+
+func (jsonFileFormat) ScanKeyPositions(stream []byte) (map[string]KeyPosition, error) {
+	return scanJsonPositions(stream)
+}
+
+type yamlFileFormat struct{}
+
+func (yamlFileFormat) Parse(stream []byte) (map[string]interface{}, error) {
+	parser := newYamlConfParser(RootPath, PathDelimiter)
+
+	if err := parser.Parse(stream); err != nil {
+		return nil, err
+	}
+
+	return parser.GetDataMap(), nil
+}
+
+func (yamlFileFormat) Encode(data map[string]interface{}) ([]byte, error) {
+	return encodeYamlValue(unflattenValue(data))
+}
+
+func (yamlFileFormat) Patch(original []byte, data map[string]interface{}) ([]byte, error) {
+	return patchYamlDocument(original, data)
+}
+
+func (yamlFileFormat) ScanKeyPositions(stream []byte) (map[string]KeyPosition, error) {
+	return scanYamlPositions(stream)
+}
+
+type tomlFileFormat struct{}
+
+func (tomlFileFormat) Parse(stream []byte) (map[string]interface{}, error) {
+	parser := newTomlConfParser(RootPath, PathDelimiter)
+
+	if err := parser.Parse(stream); err != nil {
+		return nil, err
+	}
+
+	return parser.GetDataMap(), nil
+}
+
+func (tomlFileFormat) Encode(data map[string]interface{}) ([]byte, error) {
+	nested, ok := unflattenValue(integralFloatsToInts(data)).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("[tomlFileFormat::Encode] the root of the toml document must be a table, not an array")
+	}
+
+	var buf bytes.Buffer
+
+	if err := toml.NewEncoder(&buf).Encode(nested); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// integralFloatsToInts converts the integral float64 values, which the numbers of json are decoded to,
+// into int64 so the toml encoder writes them as integers rather than as "1.0".
+func integralFloatsToInts(data map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(data))
+
+	for k, v := range data {
+		if f, ok := v.(float64); ok && f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			v = int64(f)
+		}
+
+		converted[k] = v
+	}
+
+	return converted
+}
+
+func (tomlFileFormat) ScanKeyPositions(stream []byte) (map[string]KeyPosition, error) {
+	return scanTomlPositions(stream)
+}
+
+// unflattenValue rebuilds the nested maps and slices of the flattened "/a/$0/b" keys,
+// it returns a slice when the keys of the root are array indexes.
+func unflattenValue(data map[string]interface{}) interface{} {
+	pairs := make([]KeyValuePair, 0, len(data))
+
+	for k, v := range data {
+		pairs = append(pairs, KeyValuePair{Key: k, Value: v})
+	}
+
+	root := buildConfTree(RootPath, pairs)
+	if root == nil || !root.hasChildren() {
+		return make(map[string]interface{})
+	}
+
+	return root.toValue()
+}
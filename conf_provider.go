@@ -2,19 +2,40 @@ package gconf
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
 )
 
 type confProvider struct {
-	path        string
-	data        map[string]interface{}
-	source      ConfSource
-	converter   TypeConverter
-	changeToken ChangeToken
+	path                string
+	store               *confStore
+	source              ConfSource
+	converter           TypeConverter
+	changeToken         ChangeToken
+	callbacks           []func(ConfChanges)
+	gate                func(ConfProvider, map[string]interface{}) error
+	reloadFailedHandler func(error)
+	mutex               sync.Mutex
+}
+
+// valueWriter is implemented by the sources which hold the values themselves, the values set on
+// their providers are written into the source so the next load of the source keeps them.
+type valueWriter interface {
+	setValue(key string, value interface{}) error
+	removeValue(key string, section bool) (bool, error)
+}
+
+// confChangedCallbackHolder is implemented by the sources which have their own callback of the changes.
+type confChangedCallbackHolder interface {
+	GetOnConfChangedCallback() func(ConfChanges)
 }
 
 func NewConfProvider(source ConfSource) (ConfProvider, error) {
 	p := &confProvider{
 		path:        RootPath,
+		store:       newConfStore(),
 		source:      source,
 		changeToken: NewChangeToken(),
 	}
@@ -35,7 +56,7 @@ func (c *confProvider) GetPath() string {
 func (c *confProvider) Get(key string) interface{} {
 	key = PathCombine(c.path, key)
 
-	value, exist := c.data[key]
+	value, exist := c.store.get(key)
 
 	if exist == false {
 		return nil
@@ -44,58 +65,76 @@ func (c *confProvider) Get(key string) interface{} {
 	return value
 }
 
+// Set sets the value of the key and notifies the change, the source which holds its values
+// such as MemConfSource is updated as well.
 func (c *confProvider) Set(key string, value interface{}) error {
 	key = PathCombine(c.path, key)
 
-	c.data[key] = value
+	if w, ok := c.source.(valueWriter); ok {
+		return w.setValue(key, value)
+	}
+
+	c.notify(c.store.set(key, value))
 	return nil
 }
 
-func (c *confProvider) ContainKey(key string) bool {
+// Delete removes the value of the key, an element of an array is removed with the following elements shifted down.
+func (c *confProvider) Delete(key string) error {
+	return c.delete(key, false)
+}
+
+// DeleteSection removes the key and everything under it.
+func (c *confProvider) DeleteSection(key string) error {
+	return c.delete(key, true)
+}
+
+func (c *confProvider) delete(key string, section bool) error {
 	key = PathCombine(c.path, key)
 
-	_, exist := c.data[key]
+	if w, ok := c.source.(valueWriter); ok {
+		removed, err := w.removeValue(key, section)
+		if err != nil {
+			return err
+		}
 
-	return exist
-}
+		if !removed {
+			return errors.New(fmt.Sprintf("[confProvider::Delete] the key doesn't exist: %s", key))
+		}
 
-func (c *confProvider) Keys() []string {
-	var keys []string
+		return nil
+	}
 
-	for k := range c.data {
-		keys = append(keys, k)
+	changes, ok := c.store.remove(key, section)
+	if !ok {
+		return errors.New(fmt.Sprintf("[confProvider::Delete] the key doesn't exist: %s", key))
 	}
 
-	return keys
+	c.notify(changes)
+	return nil
 }
 
-func (c *confProvider) Values() []interface{} {
-	var values []interface{}
+func (c *confProvider) ContainKey(key string) bool {
+	key = PathCombine(c.path, key)
 
-	for _, v := range c.data {
-		values = append(values, v)
-	}
+	_, exist := c.store.get(key)
 
-	return values
+	return exist
 }
 
-func (c *confProvider) ToKeyValuePairs() []KeyValuePair {
-
-	var pairs []KeyValuePair
+func (c *confProvider) Keys() []string {
+	return c.store.keys()
+}
 
-	for k, v := range c.data {
-		pair := KeyValuePair{
-			Key:   k,
-			Value: v,
-		}
-		pairs = append(pairs, pair)
-	}
+func (c *confProvider) Values() []interface{} {
+	return c.store.values()
+}
 
-	return pairs
+func (c *confProvider) ToKeyValuePairs() []KeyValuePair {
+	return c.store.pairs()
 }
 
 func (c *confProvider) IsEmpty() bool {
-	return len(c.data) == 0
+	return c.store.isEmpty()
 }
 
 func (c *confProvider) IsArray() bool {
@@ -146,6 +185,10 @@ func (c *confProvider) GetString(key string) (string, error) {
 	return c.converter.GetString(key)
 }
 
+func (c *confProvider) GetValue(key string) (interface{}, error) {
+	return c.converter.GetValue(key)
+}
+
 func (c *confProvider) TryGet(key string, defaultValue interface{}) interface{} {
 	value := c.Get(key)
 
@@ -208,6 +251,18 @@ func (c *confProvider) GetArraySection(key string) ConfArraySection {
 	return NewConfArraySection(c, PathCombine(c.path, key))
 }
 
+func (c *confProvider) Unmarshal(key string, out interface{}) error {
+	return c.converter.Unmarshal(key, out)
+}
+
+func (c *confProvider) Dump(w io.Writer, format string, options ...DumpOption) error {
+	return c.converter.Dump(w, format, options...)
+}
+
+func (c *confProvider) Query(pattern string) ([]KeyValuePair, error) {
+	return c.converter.Query(pattern)
+}
+
 func (c *confProvider) Reload() error {
 	return c.Load()
 }
@@ -216,7 +271,11 @@ func (c *confProvider) GetCallback() func(ConfChanges) {
 	return nil
 }
 
+// GetChangeToken returns the token of the next change, a fresh token is issued on every change.
 func (c *confProvider) GetChangeToken() ChangeToken {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	return c.changeToken
 }
 
@@ -227,10 +286,126 @@ func (c *confProvider) Load() error {
 		return errors.New("can't load the contents: " + err.Error())
 	}
 
-	c.data = data
+	if err := c.validate(data); err != nil {
+		return err
+	}
+
+	c.store.commit(data)
 	return nil
 }
 
+func (c *confProvider) validate(data map[string]interface{}) error {
+	c.mutex.Lock()
+	gate := c.gate
+	c.mutex.Unlock()
+
+	return validateContents(c, c.source, gate, data)
+}
+
+// OnChanged reloads the contents from the source and notifies the changes.
+func (c *confProvider) OnChanged() {
+	if err := c.reload(); err != nil {
+		log.Println("can't reload the contents: " + err.Error())
+	}
+}
+
+// reload reloads the contents from the source, the last-known-good contents are kept
+// when the new ones can't be loaded or are rejected by a validator.
+func (c *confProvider) reload() error {
+	data, err := c.source.Load()
+
+	if err == nil {
+		err = c.validate(data)
+	}
+
+	if err != nil {
+		c.fireReloadFailed(err)
+		return err
+	}
+
+	c.notify(c.store.commit(data))
+	return nil
+}
+
+func (c *confProvider) notify(changes ConfChanges) {
+	if changes.GetNumOfChanges() == 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	token := c.changeToken
+	c.changeToken = NewChangeToken()
+	callbacks := c.callbacks
+	c.mutex.Unlock()
+
+	token.OnChanged()
+
+	if h, ok := c.source.(confChangedCallbackHolder); ok && h.GetOnConfChangedCallback() != nil {
+		go h.GetOnConfChangedCallback()(changes)
+	}
+
+	for _, callback := range callbacks {
+		go callback(changes)
+	}
+}
+
+func (c *confProvider) AddOnConfChangedCallback(callback func(ConfChanges)) {
+	if callback == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.callbacks = append(c.callbacks, callback)
+}
+
+func (c *confProvider) setGate(gate func(ConfProvider, map[string]interface{}) error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.gate = gate
+}
+
+func (c *confProvider) setReloadFailedHandler(handler func(error)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.reloadFailedHandler = handler
+}
+
+func (c *confProvider) fireReloadFailed(err error) {
+	c.mutex.Lock()
+	handler := c.reloadFailedHandler
+	c.mutex.Unlock()
+
+	if handler != nil {
+		handler(err)
+	}
+}
+
+func (c *confProvider) getCommitted() uint64 {
+	return c.store.getCommitted()
+}
+
+func (c *confProvider) rollback() bool {
+	changes, ok := c.store.rollback()
+
+	if ok {
+		c.notify(changes)
+	}
+
+	return ok
+}
+
+func (c *confProvider) getVersion() uint64 {
+	return c.store.getVersion()
+}
+
+func (c *confProvider) getSource() ConfSource {
+	return c.source
+}
+
 func (c *confProvider) Dispose() {
-	c.data = nil
+	c.store.swap(nil)
 }
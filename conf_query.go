@@ -0,0 +1,264 @@
+package gconf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	anyArrayIndex     = ArrayDelimiter + "*"
+	recursiveDescent  = "**"
+	queryFilterOpen   = '['
+	queryFilterClose  = ']'
+	queryNotEqualSign = "!="
+	queryEqualSign    = "="
+)
+
+type querySegment struct {
+	name    string
+	filters []queryFilter
+}
+
+type queryFilter struct {
+	field    string
+	value    string
+	notEqual bool
+}
+
+// Query returns the pairs under the keys which match the pattern, sorted by their keys.
+// The pattern is relative to the path of the configuration and its entities are either names,
+// "*" which matches any entity, "$*" which matches any array index, or "**" which matches
+// any number of entities. An entity can be followed by filters such as "[role=primary]"
+// or "[tags/env!=dev]", which select the elements of an array, or the section itself when
+// it isn't an array, whose field has the value.
+func (t *TypeConverter) Query(pattern string) ([]KeyValuePair, error) {
+	segments, err := parseQuery(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	node := buildConfTree(t.confBase.GetPath(), t.confBase.ToKeyValuePairs())
+	if node == nil {
+		return nil, nil
+	}
+
+	data := make(map[string]interface{})
+	matchQuery(node, PathCombine(t.confBase.GetPath()), segments, data)
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+
+	sortPaths(keys)
+
+	pairs := make([]KeyValuePair, 0, len(keys))
+
+	for _, k := range keys {
+		pairs = append(pairs, KeyValuePair{Key: k, Value: data[k]})
+	}
+
+	return pairs, nil
+}
+
+func matchQuery(node *confNode, path string, segments []querySegment, data map[string]interface{}) {
+	if len(segments) == 0 {
+		node.flatten(path, data)
+		return
+	}
+
+	segment := segments[0]
+
+	if segment.name == recursiveDescent {
+		matchQuery(node, path, segments[1:], data)
+
+		for _, c := range node.children {
+			matchQuery(c, PathCombine(path, c.name), segments, data)
+		}
+
+		return
+	}
+
+	for _, c := range node.children {
+		if !segment.matchName(c.name) {
+			continue
+		}
+
+		childPath := PathCombine(path, c.name)
+
+		if len(segment.filters) == 0 {
+			matchQuery(c, childPath, segments[1:], data)
+			continue
+		}
+
+		if !c.isArray() {
+			if segment.matchFilters(c) {
+				matchQuery(c, childPath, segments[1:], data)
+			}
+			continue
+		}
+
+		for _, e := range c.children {
+			if segment.matchFilters(e) {
+				matchQuery(e, PathCombine(childPath, e.name), segments[1:], data)
+			}
+		}
+	}
+}
+
+func (s querySegment) matchName(name string) bool {
+	switch s.name {
+	case anyPathEntity:
+		return true
+	case anyArrayIndex:
+		_, ok := ParseArrayIndex(name)
+		return ok
+	}
+
+	return strings.EqualFold(s.name, name)
+}
+
+func (s querySegment) matchFilters(node *confNode) bool {
+	for _, f := range s.filters {
+		if !f.match(node) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// match compares the value of the field as a string, a missing field is equal to no value.
+func (f queryFilter) match(node *confNode) bool {
+	for _, e := range NewStringSplitter(f.field).Split(PathDelimiter, true) {
+		if node = node.child(e); node == nil {
+			break
+		}
+	}
+
+	equal := false
+
+	if node != nil && node.hasValue && node.value != nil {
+		value, err := valueToString(node.value)
+		if err != nil {
+			value = fmt.Sprint(node.value)
+		}
+
+		equal = value == f.value
+	}
+
+	return equal != f.notEqual
+}
+
+// parseQuery splits the pattern into its entities, the delimiters inside the filters don't split it.
+func parseQuery(pattern string) ([]querySegment, error) {
+	var segments []querySegment
+
+	depth := 0
+	start := 0
+
+	for i := 0; i <= len(pattern); i++ {
+		if i < len(pattern) {
+			switch pattern[i] {
+			case queryFilterOpen:
+				depth++
+				continue
+			case queryFilterClose:
+				depth--
+				continue
+			}
+
+			if depth != 0 || pattern[i:i+1] != PathDelimiter {
+				continue
+			}
+		}
+
+		if depth != 0 {
+			return nil, errors.New(fmt.Sprintf("[Query] unbalanced brackets in the pattern: %s", pattern))
+		}
+
+		if raw := strings.TrimSpace(pattern[start:i]); raw != "" {
+			segment, err := parseQuerySegment(raw)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("[Query] invalid pattern[%s]: %s", pattern, err.Error()))
+			}
+
+			segments = append(segments, segment)
+		}
+
+		start = i + 1
+	}
+
+	return segments, nil
+}
+
+func parseQuerySegment(raw string) (querySegment, error) {
+	idx := strings.IndexByte(raw, queryFilterOpen)
+	if idx == -1 {
+		return querySegment{name: raw}, nil
+	}
+
+	segment := querySegment{name: strings.TrimSpace(raw[:idx])}
+
+	if segment.name == "" {
+		return segment, errors.New(fmt.Sprintf("the filter of the entity[%s] has no name", raw))
+	}
+
+	if segment.name == recursiveDescent {
+		return segment, errors.New("the recursive descent can't be filtered")
+	}
+
+	for rest := raw[idx:]; rest != ""; {
+		end := strings.IndexByte(rest, queryFilterClose)
+
+		if rest[0] != queryFilterOpen || end == -1 {
+			return segment, errors.New(fmt.Sprintf("malformed filter: %s", rest))
+		}
+
+		filter, err := parseQueryFilter(rest[1:end])
+		if err != nil {
+			return segment, err
+		}
+
+		segment.filters = append(segment.filters, filter)
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+
+	return segment, nil
+}
+
+func parseQueryFilter(raw string) (queryFilter, error) {
+	filter := queryFilter{}
+	op := queryEqualSign
+
+	idx := strings.Index(raw, queryNotEqualSign)
+
+	if idx != -1 {
+		filter.notEqual = true
+		op = queryNotEqualSign
+	} else {
+		idx = strings.Index(raw, queryEqualSign)
+	}
+
+	if idx == -1 {
+		return filter, errors.New(fmt.Sprintf("the filter[%s] has no operator", raw))
+	}
+
+	filter.field = strings.TrimSpace(raw[:idx])
+	filter.value = unquote(strings.TrimSpace(raw[idx+len(op):]))
+
+	if filter.field == "" {
+		return filter, errors.New(fmt.Sprintf("the filter[%s] has no field", raw))
+	}
+
+	return filter, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+
+	return s
+}
@@ -0,0 +1,65 @@
+package gconf
+
+import "testing"
+
+func TestQuery(t *testing.T) {
+	root, err := NewConfBuilder().Add(NewJsonConfSource([]byte(`{
+		"servers": [
+			{ "host": "alpha", "role": "primary", "port": 1 },
+			{ "host": "beta", "role": "replica", "port": 2 },
+			{ "host": "gamma", "role": "primary", "port": 3, "meta": { "zone": "eu/west" } }
+		],
+		"clients": {
+			"orders": { "timeout": 5 },
+			"billing": { "timeout": 10, "retry": { "timeout": 1 } }
+		}
+	}`))).Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"/servers/$*/host", []string{"/servers/$0/host", "/servers/$1/host", "/servers/$2/host"}},
+		{"/servers[role=primary]/host", []string{"/servers/$0/host", "/servers/$2/host"}},
+		{"servers/$*[role!=primary][port=2]/host", []string{"/servers/$1/host"}},
+		{"/servers[meta/zone='eu/west']/port", []string{"/servers/$2/port"}},
+		{"/clients/*/timeout", []string{"/clients/billing/timeout", "/clients/orders/timeout"}},
+		{"/**/timeout", []string{"/clients/billing/retry/timeout", "/clients/billing/timeout", "/clients/orders/timeout"}},
+		{"/clients/billing/**", []string{"/clients/billing/retry/timeout", "/clients/billing/timeout"}},
+		{"/servers/$5/host", nil},
+	}
+
+	for _, test := range tests {
+		pairs, err := root.Query(test.pattern)
+		if err != nil {
+			t.Errorf("can't query the pattern[%s]: %s", test.pattern, err.Error())
+			continue
+		}
+
+		if len(pairs) != len(test.expected) {
+			t.Errorf("keys of the pattern[%s], expected: %v, returned: %v", test.pattern, test.expected, pairs)
+			continue
+		}
+
+		for i, p := range pairs {
+			if p.Key != test.expected[i] || p.Value != root.Get(p.Key) {
+				t.Errorf("pair %d of the pattern[%s], expected: %s, returned: %s", i, test.pattern, test.expected[i], p.Key)
+			}
+		}
+	}
+
+	pairs, err := root.GetSection("clients").Query("*[timeout=10]/retry")
+	if err != nil || len(pairs) != 1 || pairs[0].Key != "/clients/billing/retry/timeout" {
+		t.Errorf("query of the section[/clients], expected: [/clients/billing/retry/timeout], returned: %v, %v", pairs, err)
+	}
+
+	for _, pattern := range []string{"/servers[role=primary/host", "/servers[role]/host", "/**[role=primary]"} {
+		if _, err := root.Query(pattern); err == nil {
+			t.Errorf("query of the invalid pattern[%s], expected: an error, returned: nil", pattern)
+		}
+	}
+}
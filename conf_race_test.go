@@ -0,0 +1,175 @@
+package gconf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// The tests in this file are meant to be run with the race detector: go test -race
+
+const (
+	raceTestIterations = 200
+	raceTestReaders    = 4
+)
+
+func writeFileAtomic(t *testing.T, path string, content string) {
+	tmp := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Errorf("can't write the file[%s]: %s", tmp, err.Error())
+		return
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		t.Errorf("can't rename the file[%s]: %s", tmp, err.Error())
+	}
+}
+
+func TestConcurrentProviderGetSet(t *testing.T) {
+	provider, err := NewConfProvider(NewJsonConfSource([]byte(`{"a": {"b": 1, "c": [1, 2, 3]}}`)))
+	if err != nil {
+		t.Fatalf("can't create the provider: %s", err.Error())
+	}
+
+	var wg sync.WaitGroup
+
+	for r := 0; r < raceTestReaders; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < raceTestIterations; i++ {
+				provider.Get("/a/b")
+				provider.ContainKey("/a/c/$1")
+				provider.Keys()
+				provider.Values()
+				provider.ToKeyValuePairs()
+				provider.GetSection("a").Keys()
+				provider.TryGetInt("/a/b", 0)
+			}
+		}()
+	}
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < raceTestIterations; i++ {
+			provider.Set(fmt.Sprintf("/a/d/$%d", i), i)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < raceTestIterations; i++ {
+			if err := provider.Reload(); err != nil {
+				t.Errorf("reload returned an error: %s", err.Error())
+			}
+		}
+	}()
+
+	wg.Wait()
+}
+
+func TestConcurrentRootGetSetReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temp directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	basePath := filepath.Join(dir, "base.json")
+	prodPath := filepath.Join(dir, "prod.json")
+
+	writeFileAtomic(t, basePath, `{"logging": {"printLevel": "debug", "fileLevel": "debug"}}`)
+	writeFileAtomic(t, prodPath, `{"logging": {"printLevel": "error"}}`)
+
+	root, err := NewConfBuilder().
+		Add(NewEnvConfSource()).
+		Add(NewJsonFileConfSource(basePath)).
+		Add(NewJsonFileConfSource(prodPath)).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+	defer root.Dispose()
+
+	var providers []FileConfProvider
+	for _, p := range getProviders(root) {
+		if fp, ok := p.(FileConfProvider); ok {
+			providers = append(providers, fp)
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	for r := 0; r < raceTestReaders; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var logging struct {
+				PrintLevel string
+				FileLevel  string
+			}
+
+			for i := 0; i < raceTestIterations; i++ {
+				root.Get("/logging/printLevel")
+				root.TryGetString("logging/fileLevel", "")
+				root.Keys()
+				root.ToKeyValuePairs()
+				root.GetSection("logging").Values()
+				root.Unmarshal("logging", &logging)
+			}
+		}()
+	}
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < raceTestIterations; i++ {
+			root.Set(fmt.Sprintf("/runtime/value%d", i%10), i)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		levels := []string{"info", "warn", "error"}
+
+		for i := 0; i < raceTestIterations; i++ {
+			writeFileAtomic(t, prodPath, fmt.Sprintf(`{"logging": {"printLevel": "%s"}}`, levels[i%len(levels)]))
+
+			for _, p := range providers {
+				p.OnChanged()
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < raceTestIterations; i++ {
+			if err := root.Reload(); err != nil {
+				t.Errorf("reload returned an error: %s", err.Error())
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if level := root.TryGetString("/logging/printLevel", ""); level == "" {
+		t.Errorf("combined value after the concurrent updates, expected: a level, returned: empty")
+	}
+
+	if level := root.TryGetString("/logging/fileLevel", ""); level != "debug" {
+		t.Errorf("combined value from the base file, expected: %s, returned: %s", "debug", level)
+	}
+}
@@ -2,18 +2,54 @@ package gconf
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
 )
 
 type confRoot struct {
-	path      string
-	providers []ConfProvider
-	converter TypeConverter
-}
-
-func newConfRoot(providers []ConfProvider) ConfRoot {
+	path                  string
+	providers             []ConfProvider
+	overrides             *overrideLayer
+	arrayMergeRules       arrayMergeRules
+	registry              *ConfParserRegistry
+	converter             TypeConverter
+	snapshot              atomic.Value
+	validators            []ConfValidator
+	reloadFailedCallbacks []func(error)
+	subscribed            bool
+	notified              map[string]interface{}
+	subscribers           []changeSubscriber
+	lastSubscriberId      uint64
+	reloadedTokens        []ChangeToken
+	done                  chan struct{}
+	disposeOnce           sync.Once
+	deliveries            []changeDelivery
+	delivering            bool
+	notifyMutex           sync.Mutex
+	deliveryMutex         sync.Mutex
+	mutex                 sync.Mutex
+}
+
+// rootSnapshot is the combined map of the providers at the versions it was built from.
+// It is never modified once it is stored, a new one is built when a provider changes.
+type rootSnapshot struct {
+	versions []uint64
+	data     map[string]interface{}
+}
+
+type versionedProvider interface {
+	getVersion() uint64
+}
+
+func newConfRoot(providers []ConfProvider, validators []ConfValidator) *confRoot {
 	root := &confRoot{
-		path: RootPath,
-		providers: providers,
+		path:       RootPath,
+		providers:  providers,
+		overrides:  newOverrideLayer(nil),
+		validators: validators,
+		done:       make(chan struct{}),
 	}
 
 	root.converter = NewTypeConverter(root)
@@ -21,19 +57,46 @@ func newConfRoot(providers []ConfProvider) ConfRoot {
 	return root
 }
 
+// bindProviders lets the providers validate their reloads with the validators of the root
+// and report their failures to it.
+func (c *confRoot) bindProviders() {
+	for _, p := range c.providers {
+		g, ok := p.(gatedProvider)
+		if !ok {
+			continue
+		}
+
+		if len(c.validators) > 0 {
+			g.setGate(c.validateCandidate)
+		}
+
+		g.setReloadFailedHandler(c.onReloadFailed)
+	}
+
+	c.reloadedTokens = make([]ChangeToken, len(c.providers))
+	for i, p := range c.providers {
+		c.reloadedTokens[i] = p.GetChangeToken()
+	}
+}
+
 func (c *confRoot) GetPath() string {
 	return c.path
 }
 
+// Get returns the value of the key with its references resolved, nil when the key doesn't exist
+// or a reference can't be resolved. GetValue and the converters return the error of the resolution.
 func (c *confRoot) Get(key string) interface{} {
-	for _, p := range c.providers {
-		if !p.ContainKey(key) {
-			continue
-		}
-		return p.Get(key)
+	value, err := c.getResolved(key)
+
+	if err != nil {
+		return nil
 	}
 
-	return nil
+	return value
+}
+
+func (c *confRoot) getResolved(key string) (interface{}, error) {
+	return newInterpolator(mapLookup(c.getCombinedMap())).get(PathCombine(c.path, key))
 }
 
 func (c *confRoot) Set(key string, value interface{}) error {
@@ -50,9 +113,63 @@ func (c *confRoot) Set(key string, value interface{}) error {
 	return c.providers[0].Set(key, value)
 }
 
-func (c *confRoot) ContainKey(key string) bool {
-	for _, p := range c.providers {
-		if p.ContainKey(key) {
+// Delete removes the value of the key from every provider and the override which define it,
+// so the values of the providers of the lower priority don't show through. An element of an array
+// is removed as a whole, with the following elements shifted down.
+func (c *confRoot) Delete(key string) error {
+	return c.delete(key, false)
+}
+
+// DeleteSection removes the key and everything under it from every provider and the overrides.
+func (c *confRoot) DeleteSection(key string) error {
+	return c.delete(key, true)
+}
+
+func (c *confRoot) delete(key string, section bool) error {
+	key = PathCombine(c.path, key)
+	section = section || isArrayElementKey(key)
+	found := false
+
+	// the merged arrays don't have the indexes of the providers, so the key is mapped back
+	// to the keys it was merged from
+	for _, o := range c.arrayMergeRules.findOrigins(c.providers, key) {
+		if !containKeyOrSection(o.provider, o.key, section) {
+			continue
+		}
+
+		found = true
+
+		var err error
+		if section {
+			err = o.provider.DeleteSection(o.key)
+		} else {
+			err = o.provider.Delete(o.key)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.clearOverrides(key, section) {
+		found = true
+	}
+
+	if !found {
+		return errors.New(fmt.Sprintf("[confRoot::Delete] the key doesn't exist: %s", key))
+	}
+
+	c.notifyChanges()
+	return nil
+}
+
+func containKeyOrSection(conf ConfBase, key string, section bool) bool {
+	if !section {
+		return conf.ContainKey(key)
+	}
+
+	for _, k := range conf.Keys() {
+		if IsKeyInPath(key, k) {
 			return true
 		}
 	}
@@ -60,6 +177,17 @@ func (c *confRoot) ContainKey(key string) bool {
 	return false
 }
 
+// GetChildren returns the top level sections.
+func (c *confRoot) GetChildren() []ConfSection {
+	return getChildSections(c, c.path, c.Keys())
+}
+
+func (c *confRoot) ContainKey(key string) bool {
+	_, exist := c.getCombinedMap()[PathCombine(c.path, key)]
+
+	return exist
+}
+
 func (c *confRoot) Keys() []string {
 	pairMap := c.getCombinedMap()
 
@@ -74,11 +202,12 @@ func (c *confRoot) Keys() []string {
 
 func (c *confRoot) Values() []interface{} {
 	pairMap := c.getCombinedMap()
+	resolver := newInterpolator(mapLookup(pairMap))
 
 	var values []interface{}
 
-	for _, v := range pairMap {
-		values = append(values, v)
+	for k := range pairMap {
+		values = append(values, resolveOrRaw(resolver, pairMap, k))
 	}
 
 	return values
@@ -86,12 +215,13 @@ func (c *confRoot) Values() []interface{} {
 
 func (c *confRoot) ToKeyValuePairs() []KeyValuePair {
 	pairMap := c.getCombinedMap()
+	resolver := newInterpolator(mapLookup(pairMap))
 
 	var pairs []KeyValuePair
-	for k, v := range pairMap {
+	for k := range pairMap {
 		pair := KeyValuePair{
 			Key:   k,
-			Value: v,
+			Value: resolveOrRaw(resolver, pairMap, k),
 		}
 		pairs = append(pairs, pair)
 	}
@@ -99,32 +229,87 @@ func (c *confRoot) ToKeyValuePairs() []KeyValuePair {
 	return pairs
 }
 
-func (c *confRoot) IsEmpty() bool {
-	for _, p := range c.providers {
-		if !p.IsEmpty() {
-			return false
-		}
+// resolveOrRaw returns the resolved value of the key or the raw value when it can't be resolved.
+func resolveOrRaw(resolver *interpolator, data map[string]interface{}, key string) interface{} {
+	value, err := resolver.get(key)
+
+	if err != nil {
+		return data[key]
 	}
 
-	return true
+	return value
+}
+
+func (c *confRoot) IsEmpty() bool {
+	return len(c.getCombinedMap()) == 0
 }
 
 func (c *confRoot) IsArray() bool {
 	return c.GetSection(c.path).IsArray()
 }
 
+// getCombinedMap returns the current snapshot of the combined map,
+// the returned map is shared between the readers and must not be modified.
 func (c *confRoot) getCombinedMap() map[string]interface{} {
-	pairMap := make(map[string]interface{})
+	versions, versioned := c.getProviderVersions()
 
-	for i := len(c.providers) - 1; i >= 0; i-- {
-		subPairs := c.providers[i].ToKeyValuePairs()
+	if versioned {
+		if s, ok := c.snapshot.Load().(*rootSnapshot); ok && equalVersions(s.versions, versions) {
+			return s.data
+		}
+	}
 
-		if subPairs == nil || len(subPairs) == 0 {
-			continue
+	data := c.combineProviders()
+
+	if versioned {
+		c.snapshot.Store(&rootSnapshot{
+			versions: versions,
+			data:     data,
+		})
+	}
+
+	return data
+}
+
+func (c *confRoot) getProviderVersions() ([]uint64, bool) {
+	versions := make([]uint64, len(c.providers))
+
+	for i, p := range c.providers {
+		v, ok := p.(versionedProvider)
+
+		if !ok {
+			return nil, false
+		}
+
+		versions[i] = v.getVersion()
+	}
+
+	return append(versions, c.overrides.getVersion()), true
+}
+
+func equalVersions(a []uint64, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
+	}
+
+	return true
+}
+
+// combineProviders merges the providers by the array merge rules and applies the overrides on top.
+func (c *confRoot) combineProviders() map[string]interface{} {
+	pairMap := c.arrayMergeRules.mergeProviders(c.providers)
+
+	for _, o := range c.overrides.inOrder() {
+		shadowKey(pairMap, o.Key)
 
-		for _, p := range subPairs {
-			pairMap[p.Key] = p.Value
+		if o.Value != nil {
+			pairMap[o.Key] = o.Value
 		}
 	}
 
@@ -175,6 +360,10 @@ func (c *confRoot) GetString(key string) (string, error) {
 	return c.converter.GetString(key)
 }
 
+func (c *confRoot) GetValue(key string) (interface{}, error) {
+	return c.converter.GetValue(key)
+}
+
 func (c *confRoot) TryGet(key string, defaultValue interface{}) interface{} {
 	result := c.Get(key)
 
@@ -237,21 +426,73 @@ func (c *confRoot) GetArraySection(key string) ConfArraySection {
 	return NewConfArraySection(c, PathCombine(c.path, key))
 }
 
+func (c *confRoot) Unmarshal(key string, out interface{}) error {
+	return c.converter.Unmarshal(key, out)
+}
+
+func (c *confRoot) Dump(w io.Writer, format string, options ...DumpOption) error {
+	return c.converter.Dump(w, format, options...)
+}
+
+func (c *confRoot) Query(pattern string) ([]KeyValuePair, error) {
+	return c.converter.Query(pattern)
+}
+
+func (c *confRoot) getConfParserRegistry() *ConfParserRegistry {
+	return c.registry
+}
+
+func (c *confRoot) GetProviders() []ConfProvider {
+	return c.providers
+}
+
+// GetChangeToken returns the token which changes when any of the providers changes.
+func (c *confRoot) GetChangeToken() ChangeToken {
+	tokens := make([]ChangeToken, len(c.providers), len(c.providers)+1)
+
+	for i, p := range c.providers {
+		tokens[i] = p.GetChangeToken()
+	}
+
+	tokens = append(tokens, c.overrides.GetChangeToken())
+
+	return NewCompositeChangeToken(tokens...)
+}
+
+// Reload reloads the providers which have changed since the last reload.
 func (c *confRoot) Reload() error {
-	for _, p := range c.providers {
-		if !p.GetChangeToken().HasChanged() {
+	c.mutex.Lock()
+	reloadedTokens := append([]ChangeToken(nil), c.reloadedTokens...)
+	c.mutex.Unlock()
+
+	for i, p := range c.providers {
+		if i < len(reloadedTokens) && reloadedTokens[i] != nil && !reloadedTokens[i].HasChanged() {
 			continue
 		}
 
+		token := p.GetChangeToken()
+
 		if err := p.Load(); err != nil {
+			c.onReloadFailed(err)
 			return err
 		}
+
+		c.mutex.Lock()
+		if i < len(c.reloadedTokens) {
+			c.reloadedTokens[i] = token
+		}
+		c.mutex.Unlock()
 	}
 
+	c.notifyChanges()
 	return nil
 }
 
 func (c *confRoot) Dispose() {
+	c.disposeOnce.Do(func() {
+		close(c.done)
+	})
+
 	for _, p := range c.providers {
 		p.Dispose()
 	}
@@ -0,0 +1,158 @@
+package gconf
+
+type changeSubscriber struct {
+	id       uint64
+	path     string
+	callback func(ConfChanges)
+}
+
+// OnChange registers the callback of the changes of the merged view under the prefix.
+// The changes are calculated after any provider reloads, so the changes of the keys
+// which are shadowed by a provider of the higher priority aren't notified.
+// Unregister the returned registration to stop the notifications.
+func (c *confRoot) OnChange(prefix string, callback func(ConfChanges)) ChangeTokenRegistration {
+	if callback == nil {
+		return &subscriberRegistration{}
+	}
+
+	return &subscriberRegistration{
+		root: c,
+		id:   c.subscribe(prefix, callback),
+	}
+}
+
+type subscriberRegistration struct {
+	root *confRoot
+	id   uint64
+}
+
+func (r *subscriberRegistration) Unregister() {
+	if r.root == nil {
+		return
+	}
+
+	r.root.unsubscribe(r.id)
+}
+
+func (c *confRoot) subscribe(prefix string, callback func(ConfChanges)) uint64 {
+	c.mutex.Lock()
+	c.lastSubscriberId++
+	id := c.lastSubscriberId
+
+	c.subscribers = append(c.subscribers, changeSubscriber{
+		id:       id,
+		path:     PathCombine(c.path, prefix),
+		callback: callback,
+	})
+
+	subscribed := c.subscribed
+	if !subscribed {
+		c.notified = c.getCombinedMap()
+		c.subscribed = true
+	}
+	c.mutex.Unlock()
+
+	if subscribed {
+		return id
+	}
+
+	for _, p := range c.providers {
+		if n, ok := p.(ConfChangeNotifier); ok {
+			n.AddOnConfChangedCallback(c.onProviderChanged)
+		}
+	}
+
+	return id
+}
+
+func (c *confRoot) unsubscribe(id uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// the slice is copied since notifyChanges may be iterating the current one
+	subscribers := make([]changeSubscriber, 0, len(c.subscribers))
+
+	for _, s := range c.subscribers {
+		if s.id != id {
+			subscribers = append(subscribers, s)
+		}
+	}
+
+	c.subscribers = subscribers
+}
+
+func (c *confRoot) onProviderChanged(changes ConfChanges) {
+	c.notifyChanges()
+}
+
+// notifyChanges compares the merged view with the one of the last notification
+// and passes the differences to the subscribers in the order they happened.
+func (c *confRoot) notifyChanges() {
+	c.notifyMutex.Lock()
+
+	c.mutex.Lock()
+	if !c.subscribed {
+		c.mutex.Unlock()
+		c.notifyMutex.Unlock()
+		return
+	}
+
+	subscribers := c.subscribers
+	prev := c.notified
+	current := c.getCombinedMap()
+	c.notified = current
+	c.mutex.Unlock()
+
+	changes := CalcConfChanges(current, prev)
+
+	if changes.GetNumOfChanges() > 0 {
+		c.deliveryMutex.Lock()
+		c.deliveries = append(c.deliveries, changeDelivery{subscribers: subscribers, changes: changes})
+		c.deliveryMutex.Unlock()
+	}
+
+	c.notifyMutex.Unlock()
+
+	c.deliverChanges()
+}
+
+// changeDelivery is the changes of one notification with the subscribers at the time.
+type changeDelivery struct {
+	subscribers []changeSubscriber
+	changes     ConfChanges
+}
+
+// deliverChanges calls the subscribers with the queued changes in order. Only one goroutine delivers
+// at a time and the others leave their changes in the queue, so a subscriber which changes the root
+// from its callback gets the new changes after the current ones instead of deadlocking.
+func (c *confRoot) deliverChanges() {
+	c.deliveryMutex.Lock()
+
+	if c.delivering {
+		c.deliveryMutex.Unlock()
+		return
+	}
+
+	c.delivering = true
+
+	for len(c.deliveries) > 0 {
+		d := c.deliveries[0]
+		c.deliveries = c.deliveries[1:]
+		c.deliveryMutex.Unlock()
+
+		for _, s := range d.subscribers {
+			filtered := FilterConfChanges(d.changes, s.path)
+
+			if filtered.GetNumOfChanges() == 0 {
+				continue
+			}
+
+			s.callback(filtered)
+		}
+
+		c.deliveryMutex.Lock()
+	}
+
+	c.delivering = false
+	c.deliveryMutex.Unlock()
+}
@@ -0,0 +1,109 @@
+package gconf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRootOnChange(t *testing.T) {
+	base := NewMapConfSource(map[string]interface{}{
+		"database": map[string]interface{}{"host": "base.local", "port": 5432},
+		"logging":  map[string]interface{}{"level": "info"},
+	})
+
+	override := NewMapConfSource(map[string]interface{}{
+		"database": map[string]interface{}{"host": "override.local"},
+	})
+
+	root, err := NewConfBuilder().Add(base).Add(override).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	notified := make(chan ConfChanges, 10)
+
+	registration := root.OnChange("database", func(changes ConfChanges) {
+		notified <- changes
+	})
+
+	base.Set("database/host", "shadowed.local")
+	base.Set("logging/level", "debug")
+	base.Set("database/port", 6543)
+
+	select {
+	case changes := <-notified:
+		if changes.GetNumOfChanges() != 1 {
+			t.Fatalf("number of changes, expected: %d, returned: %d", 1, changes.GetNumOfChanges())
+		}
+
+		c := changes.GetChanges()[0]
+		if c.KeyName != "/database/port" || c.Mode != Modified || c.Current != 6543 {
+			t.Errorf("change of the merged view, expected: %s, returned: %s", "[modified] key: /database/port, prev: 5432, current: 6543", c.String())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the change of database/port wasn't notified")
+	}
+
+	select {
+	case changes := <-notified:
+		t.Errorf("unexpected notification of the shadowed or unrelated keys: %v", changes.GetChanges())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	registration.Unregister()
+	base.Set("database/port", 7654)
+
+	select {
+	case changes := <-notified:
+		t.Errorf("unexpected notification after the unregistration: %v", changes.GetChanges())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRootOnChangeReentrant(t *testing.T) {
+	root, err := NewConfBuilder().Add(NewMapConfSource(map[string]interface{}{"a": 1, "b": 1})).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	var order []string
+
+	root.OnChange("a", func(ConfChanges) {
+		order = append(order, "a")
+
+		if err := root.SetOverride("b", 3, "callback"); err != nil {
+			t.Errorf("can't set the override from the callback: %s", err.Error())
+		}
+
+		order = append(order, "a done")
+	})
+
+	root.OnChange("b", func(ConfChanges) {
+		order = append(order, "b")
+	})
+
+	done := make(chan error)
+
+	go func() {
+		done <- root.SetOverride("a", 5, "test")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("can't set the override: %s", err.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("SetOverride from the callback deadlocked")
+	}
+
+	expected := []string{"a", "a done", "b"}
+
+	if len(order) != len(expected) || order[0] != expected[0] || order[1] != expected[1] || order[2] != expected[2] {
+		t.Errorf("order of the callbacks, expected: %v, returned: %v", expected, order)
+	}
+
+	if root.TryGetInt("b", 0) != 3 {
+		t.Errorf("value set from the callback, expected: 3, returned: %d", root.TryGetInt("b", 0))
+	}
+}
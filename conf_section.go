@@ -1,16 +1,17 @@
 package gconf
 
 import (
+	"io"
 	"log"
 )
 
 type confSection struct {
 	path      string
-	root      ConfRoot
+	root      Conf
 	converter TypeConverter
 }
 
-func NewConfSection(root ConfRoot, path string) ConfSection {
+func NewConfSection(root Conf, path string) ConfSection {
 	if root == nil {
 		log.Fatal("[confSection::NewConfSection] invalid null argument: root")
 	}
@@ -28,18 +29,82 @@ func NewConfSection(root ConfRoot, path string) ConfSection {
 	return s
 }
 
+// getChildSections returns the sections of the distinct entities which follow the path in the keys,
+// the entities are sorted and the array indexes are in numeric order.
+func getChildSections(root Conf, path string, keys []string) []ConfSection {
+	path = PathCombine(path)
+	found := make(map[string]bool)
+	var names []string
+
+	for _, k := range keys {
+		k = PathCombine(k)
+
+		if len(k) == len(path) || !IsKeyInPath(path, k) {
+			continue
+		}
+
+		name := NewStringSplitter(k[len(path):]).Split(PathDelimiter, true)[0]
+
+		if !found[name] {
+			found[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sortPaths(names)
+
+	children := make([]ConfSection, 0, len(names))
+
+	for _, name := range names {
+		children = append(children, NewConfSection(root, PathCombine(path, name)))
+	}
+
+	return children
+}
+
 func (c *confSection) GetPath() string {
 	return c.path
 }
 
+func (c *confSection) getConfParserRegistry() *ConfParserRegistry {
+	return confParserRegistryOf(c.root)
+}
+
 func (c *confSection) Get(key string) interface{} {
 	return c.root.Get(PathCombine(c.path, key))
 }
 
+func (c *confSection) getResolved(key string) (interface{}, error) {
+	return resolveValue(c.root, PathCombine(c.path, key))
+}
+
 func (c *confSection) Set(key string, value interface{}) error {
 	return c.root.Set(PathCombine(c.path, key), value)
 }
 
+func (c *confSection) Delete(key string) error {
+	return c.root.Delete(PathCombine(c.path, key))
+}
+
+func (c *confSection) DeleteSection(key string) error {
+	return c.root.DeleteSection(PathCombine(c.path, key))
+}
+
+// Key returns the last entity of the path of the section, such as "db" of "/clients/db".
+func (c *confSection) Key() string {
+	return GetSectionKey(c.path)
+}
+
+// Value returns the value of the path of the section, it returns nil when the section has no value of its own.
+func (c *confSection) Value() interface{} {
+	return c.root.Get(c.path)
+}
+
+// GetChildren returns the immediate child sections, one for every distinct entity which follows the path.
+func (c *confSection) GetChildren() []ConfSection {
+	return getChildSections(c.root, c.path, c.root.Keys())
+}
+
 func (c *confSection) ContainKey(key string) bool {
 	return c.root.ContainKey(PathCombine(c.path, key))
 }
@@ -64,7 +129,7 @@ func (c *confSection) Values() []interface{} {
 	var values []interface{}
 
 	for _, p := range pairs {
-		if HasPathInKey(c.path, p.Key) {
+		if IsKeyInPath(c.path, p.Key) {
 			values = append(values, p.Value)
 		}
 	}
@@ -90,7 +155,7 @@ func (c *confSection) IsEmpty() bool {
 	}
 
 	for _, p := range pairs {
-		if HasPathInKey(c.path, p.Key) {
+		if IsKeyInPath(c.path, p.Key) {
 			return false
 		}
 	}
@@ -146,6 +211,10 @@ func (c *confSection) GetString(key string) (string, error) {
 	return c.converter.GetString(key)
 }
 
+func (c *confSection) GetValue(key string) (interface{}, error) {
+	return c.converter.GetValue(key)
+}
+
 func (c *confSection) TryGet(key string, defaultValue interface{}) interface{} {
 	return c.root.TryGet(PathCombine(c.path, key), defaultValue)
 }
@@ -201,3 +270,15 @@ func (c *confSection) GetSection(key string) ConfSection {
 func (c *confSection) GetArraySection(key string) ConfArraySection {
 	return c.root.GetArraySection(PathCombine(c.path, key))
 }
+
+func (c *confSection) Unmarshal(key string, out interface{}) error {
+	return c.converter.Unmarshal(key, out)
+}
+
+func (c *confSection) Dump(w io.Writer, format string, options ...DumpOption) error {
+	return c.converter.Dump(w, format, options...)
+}
+
+func (c *confSection) Query(pattern string) ([]KeyValuePair, error) {
+	return c.converter.Query(pattern)
+}
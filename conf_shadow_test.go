@@ -0,0 +1,73 @@
+package gconf
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestShadowingAndTombstones(t *testing.T) {
+	base := NewJsonConfSource([]byte(`{
+		"db": { "host": "db.local", "port": 5432 },
+		"cache": "memory",
+		"feature": { "enabled": true, "limit": 10 },
+		"debug": true
+	}`))
+
+	upper := NewYamlConfSource([]byte(`
+db: "sqlite://app.db"
+cache:
+  host: redis.local
+feature:
+  limit: ~
+debug: null
+`))
+
+	root, err := NewConfBuilder().Add(base).Add(upper).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	keys := root.Keys()
+	sort.Strings(keys)
+
+	expected := []string{"/cache/host", "/db", "/feature/enabled"}
+
+	if len(keys) != len(expected) {
+		t.Fatalf("keys of the merged view, expected: %v, returned: %v", expected, keys)
+	}
+
+	for i := range expected {
+		if keys[i] != expected[i] {
+			t.Errorf("keys of the merged view, expected: %v, returned: %v", expected, keys)
+			break
+		}
+	}
+
+	if root.GetSection("db").ContainKey("host") {
+		t.Errorf("the scalar doesn't shadow the section[/db]: %v", root.GetSection("db").Keys())
+	}
+
+	if err := root.SetOverride("cache", "none", "test"); err != nil {
+		t.Fatalf("can't set the override: %s", err.Error())
+	}
+
+	if root.ContainKey("cache/host") || root.Get("cache") != "none" {
+		t.Errorf("the override doesn't shadow the section[/cache]: %v", root.Keys())
+	}
+
+	if err := root.SetOverride("db/host", "db.prod", "test"); err != nil {
+		t.Fatalf("can't set the override: %s", err.Error())
+	}
+
+	if root.ContainKey("db") || root.Get("db/host") != "db.prod" {
+		t.Errorf("the override doesn't shadow the scalar[/db]: %v", root.Keys())
+	}
+
+	if err := root.SetOverride("feature/enabled", nil, "test"); err != nil {
+		t.Fatalf("can't set the override: %s", err.Error())
+	}
+
+	if root.ContainKey("feature/enabled") {
+		t.Errorf("the null override doesn't remove the key[/feature/enabled]")
+	}
+}
@@ -0,0 +1,259 @@
+package gconf
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// confStore holds the key/value map of a configuration provider as an immutable snapshot.
+// Readers load the current snapshot without any lock, writers build a new map
+// and swap it atomically, so a snapshot is never modified once it is stored.
+type confStore struct {
+	data    atomic.Value
+	version uint64
+	history []storeCommit
+	mutex   sync.Mutex
+}
+
+// storeCommit is the snapshot a commit replaced, with the sequence of the commit.
+type storeCommit struct {
+	prev     map[string]interface{}
+	sequence uint64
+}
+
+// MaxRollbackDepth is the number of the commits each provider keeps to roll back.
+const MaxRollbackDepth = 10
+
+// commitSequence orders the commits of all the stores, so the root can roll back the latest one.
+var commitSequence uint64
+
+func newConfStore() *confStore {
+	s := &confStore{}
+	s.data.Store(make(map[string]interface{}))
+
+	return s
+}
+
+func (s *confStore) snapshot() map[string]interface{} {
+	return s.data.Load().(map[string]interface{})
+}
+
+func (s *confStore) getVersion() uint64 {
+	return atomic.LoadUint64(&s.version)
+}
+
+func (s *confStore) get(key string) (interface{}, bool) {
+	value, exist := s.snapshot()[normalizeKey(key)]
+	return value, exist
+}
+
+func (s *confStore) keys() []string {
+	var keys []string
+
+	for k := range s.snapshot() {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func (s *confStore) values() []interface{} {
+	var values []interface{}
+
+	for _, v := range s.snapshot() {
+		values = append(values, v)
+	}
+
+	return values
+}
+
+func (s *confStore) pairs() []KeyValuePair {
+	var pairs []KeyValuePair
+
+	for k, v := range s.snapshot() {
+		pair := KeyValuePair{
+			Key:   k,
+			Value: v,
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return pairs
+}
+
+func (s *confStore) isEmpty() bool {
+	return len(s.snapshot()) == 0
+}
+
+// set stores the value on a copy of the current snapshot and returns the changes.
+func (s *confStore) set(key string, value interface{}) ConfChanges {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	prev := s.snapshot()
+	data := copyMap(prev)
+	data[normalizeKey(key)] = value
+
+	return s.store(data, prev)
+}
+
+// remove removes the key from a copy of the current snapshot and returns the changes,
+// it reports false when there is nothing to remove.
+func (s *confStore) remove(key string, section bool) (ConfChanges, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	prev := s.snapshot()
+	data := copyMap(prev)
+
+	if !removeKeys(data, key, section) {
+		return EmptyConfChanges(), false
+	}
+
+	return s.store(data, prev), true
+}
+
+// swap replaces the whole snapshot and returns the changes from the previous one.
+func (s *confStore) swap(data map[string]interface{}) ConfChanges {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.store(data, s.snapshot())
+}
+
+// commit replaces the whole snapshot like swap, and keeps the previous one for the rollback
+// unless it is the first load of the store or nothing is changed. Only the last
+// MaxRollbackDepth snapshots are kept.
+func (s *confStore) commit(data map[string]interface{}) ConfChanges {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	prev := s.snapshot()
+	changes := s.store(data, prev)
+
+	if s.getVersion() > 1 && changes.GetNumOfChanges() > 0 {
+		s.history = append(s.history, storeCommit{
+			prev:     prev,
+			sequence: atomic.AddUint64(&commitSequence, 1),
+		})
+
+		if len(s.history) > MaxRollbackDepth {
+			s.history = s.history[len(s.history)-MaxRollbackDepth:]
+		}
+	}
+
+	return changes
+}
+
+// rollback restores the snapshot before the last commit, the earlier commits are
+// rolled back by the following calls.
+func (s *confStore) rollback() (ConfChanges, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.history) == 0 {
+		return EmptyConfChanges(), false
+	}
+
+	last := s.history[len(s.history)-1]
+	s.history = s.history[:len(s.history)-1]
+
+	return s.store(last.prev, s.snapshot()), true
+}
+
+// getCommitted returns the sequence of the last commit which can be rolled back, or zero.
+func (s *confStore) getCommitted() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.history) == 0 {
+		return 0
+	}
+
+	return s.history[len(s.history)-1].sequence
+}
+
+func (s *confStore) store(data map[string]interface{}, prev map[string]interface{}) ConfChanges {
+	changes := CalcConfChanges(data, prev)
+
+	s.data.Store(data)
+	atomic.AddUint64(&s.version, 1)
+
+	return changes
+}
+
+func normalizeKey(key string) string {
+	return PathCombine(RootPath, key)
+}
+
+// removeKeys removes the key from the data, and everything under it when section is set
+// or the key is an element of an array.
+// When the key is an element of an array the following elements are shifted down,
+// so the indexes of the array stay contiguous. It reports whether anything was removed.
+func removeKeys(data map[string]interface{}, key string, section bool) bool {
+	key = normalizeKey(key)
+	section = section || isArrayElementKey(key)
+	removed := false
+
+	for k := range data {
+		if k == key || (section && IsKeyInPath(key, k)) {
+			delete(data, k)
+			removed = true
+		}
+	}
+
+	if !removed || key == RootPath {
+		return removed
+	}
+
+	idx := strings.LastIndex(key, PathDelimiter)
+
+	if removedIdx, ok := ParseArrayIndex(key[idx+1:]); ok {
+		shiftArrayElements(data, PathCombine(RootPath, key[:idx]), removedIdx)
+	}
+
+	return true
+}
+
+// isArrayElementKey tells whether the key is an element of an array, which is removed with everything under it.
+func isArrayElementKey(key string) bool {
+	idx := strings.LastIndex(key, PathDelimiter)
+	_, ok := ParseArrayIndex(key[idx+1:])
+
+	return ok
+}
+
+// shiftArrayElements moves the elements of the array which follow the removed index down by one.
+func shiftArrayElements(data map[string]interface{}, arrayPath string, removedIdx int) {
+	moved := make(map[string]interface{})
+
+	for k, v := range data {
+		if k == arrayPath || !IsKeyInPath(arrayPath, k) {
+			continue
+		}
+
+		entities := NewStringSplitter(k[len(arrayPath):]).Split(PathDelimiter, true)
+
+		idx, ok := ParseArrayIndex(entities[0])
+		if !ok || idx < removedIdx {
+			continue
+		}
+
+		entities[0] = GetArrayIndex(idx - 1)
+		moved[PathCombine(append([]string{arrayPath}, entities...)...)] = v
+		delete(data, k)
+	}
+
+	for k, v := range moved {
+		data[k] = v
+	}
+}
@@ -0,0 +1,165 @@
+package gconf
+
+import (
+	"sort"
+	"strings"
+)
+
+// confNode is a nested view of the flattened "/a/b/$0/c" keys which is used
+// whenever the configuration has to be handled as a tree instead of a list.
+type confNode struct {
+	name     string
+	path     string
+	value    interface{}
+	hasValue bool
+	children []*confNode
+	index    map[string]*confNode
+	origins  []confOrigin
+}
+
+func newConfNode(name string, path string) *confNode {
+	return &confNode{
+		name:  name,
+		path:  path,
+		index: make(map[string]*confNode),
+	}
+}
+
+// buildConfTree builds the tree of every pair placed under basePath.
+// It returns nil when there is no pair under basePath.
+func buildConfTree(basePath string, pairs []KeyValuePair) *confNode {
+	baseEntities := NewStringSplitter(basePath).Split(PathDelimiter, true)
+	root := newConfNode(GetSectionKey(PathCombine(basePath)), PathCombine(basePath))
+	found := false
+
+	for _, p := range pairs {
+		entities := NewStringSplitter(p.Key).Split(PathDelimiter, true)
+
+		if !hasPathEntities(baseEntities, entities) {
+			continue
+		}
+
+		found = true
+		node := root
+
+		for _, e := range entities[len(baseEntities):] {
+			node = node.addChild(e)
+		}
+
+		node.value = p.Value
+		node.hasValue = true
+	}
+
+	if !found {
+		return nil
+	}
+
+	return root
+}
+
+func hasPathEntities(base []string, entities []string) bool {
+	if len(entities) < len(base) {
+		return false
+	}
+
+	for i, b := range base {
+		if !strings.EqualFold(b, entities[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n *confNode) addChild(name string) *confNode {
+	if child := n.child(name); child != nil {
+		return child
+	}
+
+	child := newConfNode(name, PathCombine(n.path, name))
+	n.children = append(n.children, child)
+	n.index[strings.ToLower(name)] = child
+
+	return child
+}
+
+func (n *confNode) child(name string) *confNode {
+	return n.index[strings.ToLower(name)]
+}
+
+func (n *confNode) hasChildren() bool {
+	return len(n.children) != 0
+}
+
+func (n *confNode) isArray() bool {
+	if !n.hasChildren() {
+		return false
+	}
+
+	for _, c := range n.children {
+		if _, ok := ParseArrayIndex(c.name); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n *confNode) arrayLength() int {
+	length := 0
+
+	for _, c := range n.children {
+		if idx, ok := ParseArrayIndex(c.name); ok && idx+1 > length {
+			length = idx + 1
+		}
+	}
+
+	return length
+}
+
+// sortedChildren returns the children in a deterministic order,
+// array indexes by their number and the others by their name.
+func (n *confNode) sortedChildren() []*confNode {
+	children := make([]*confNode, len(n.children))
+	copy(children, n.children)
+
+	sort.SliceStable(children, func(i, j int) bool {
+		li, iok := ParseArrayIndex(children[i].name)
+		lj, jok := ParseArrayIndex(children[j].name)
+
+		if iok && jok {
+			return li < lj
+		}
+
+		return children[i].name < children[j].name
+	})
+
+	return children
+}
+
+// toValue converts the node into plain go values: a []interface{} for
+// arrays, a map[string]interface{} for objects and the value for leaves.
+func (n *confNode) toValue() interface{} {
+	if !n.hasChildren() {
+		return n.value
+	}
+
+	if n.isArray() {
+		values := make([]interface{}, n.arrayLength())
+
+		for _, c := range n.children {
+			idx, _ := ParseArrayIndex(c.name)
+			values[idx] = c.toValue()
+		}
+
+		return values
+	}
+
+	values := make(map[string]interface{})
+
+	for _, c := range n.children {
+		values[c.name] = c.toValue()
+	}
+
+	return values
+}
@@ -0,0 +1,167 @@
+package gconf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConfValidator validates the configuration before it's committed. When it returns an error
+// the reload is rejected and the last-known-good configuration is kept.
+type ConfValidator func(conf Conf) error
+
+// validatorHolder is implemented by the sources which validate their own contents.
+type validatorHolder interface {
+	GetValidator() ConfValidator
+}
+
+// gatedProvider is implemented by the providers which let the root validate
+// the reloaded contents before committing them, and roll back their last commit.
+type gatedProvider interface {
+	setGate(gate func(p ConfProvider, data map[string]interface{}) error)
+	setReloadFailedHandler(handler func(error))
+	getCommitted() uint64
+	rollback() bool
+}
+
+// validationError is the error of the contents which are loaded but rejected by a validator,
+// unlike the errors of the loading they aren't fixed by retrying.
+type validationError struct {
+	err error
+}
+
+func (e *validationError) Error() string {
+	return e.err.Error()
+}
+
+func isValidationError(err error) bool {
+	_, ok := err.(*validationError)
+	return ok
+}
+
+// validateContents runs the validator of the source and the gate of the root on the contents
+// the provider is going to commit.
+func validateContents(p ConfProvider, source ConfSource, gate func(ConfProvider, map[string]interface{}) error, data map[string]interface{}) error {
+	if h, ok := source.(validatorHolder); ok && h.GetValidator() != nil {
+		if err := h.GetValidator()(newDataConf(data)); err != nil {
+			return &validationError{
+				err: errors.New(fmt.Sprintf("the contents of %s are rejected by its validator: %s", describeType(source), err.Error())),
+			}
+		}
+	}
+
+	if gate != nil {
+		if err := gate(p, data); err != nil {
+			return &validationError{
+				err: errors.New(fmt.Sprintf("the contents of %s are rejected by the validator of the root: %s", describeType(source), err.Error())),
+			}
+		}
+	}
+
+	return nil
+}
+
+// newDataConf returns a configuration of the flattened contents without a source.
+func newDataConf(data map[string]interface{}) *confProvider {
+	p := &confProvider{
+		path:        RootPath,
+		store:       newConfStore(),
+		changeToken: NewChangeToken(),
+	}
+
+	p.store.swap(data)
+	p.converter = NewTypeConverter(p)
+
+	return p
+}
+
+// validateCandidate runs the validators of the root on the merged view
+// in which the contents of the provider are replaced by the data.
+func (c *confRoot) validateCandidate(p ConfProvider, data map[string]interface{}) error {
+	if len(c.validators) == 0 {
+		return nil
+	}
+
+	providers := make([]ConfProvider, len(c.providers))
+
+	for i, provider := range c.providers {
+		if provider == p {
+			providers[i] = newDataConf(data)
+		} else {
+			providers[i] = provider
+		}
+	}
+
+	candidate := c.newCandidate(providers)
+	candidate.overrides = c.overrides
+
+	return validateConf(candidate, c.validators)
+}
+
+// newCandidate returns a root of the providers which merges them the same way as the root,
+// the validators are run on it before the changes are committed.
+func (c *confRoot) newCandidate(providers []ConfProvider) *confRoot {
+	candidate := newConfRoot(providers, nil)
+	candidate.arrayMergeRules = c.arrayMergeRules
+
+	return candidate
+}
+
+func validateConf(conf Conf, validators []ConfValidator) error {
+	for _, v := range validators {
+		if err := v(conf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// OnReloadFailed registers the callback of the reloads which fail to load or are rejected by a validator.
+func (c *confRoot) OnReloadFailed(callback func(err error)) {
+	if callback == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.reloadFailedCallbacks = append(c.reloadFailedCallbacks, callback)
+}
+
+func (c *confRoot) onReloadFailed(err error) {
+	c.mutex.Lock()
+	callbacks := c.reloadFailedCallbacks
+	c.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback(err)
+	}
+}
+
+// Rollback restores the contents of the provider which was changed last to the snapshot before the change.
+// Every call undoes one more commit, across the providers in the order they were committed, up to
+// MaxRollbackDepth commits of each provider. The overrides aren't rolled back, use ClearOverride,
+// and the values set on the provider after the commit without a reload are lost with it.
+func (c *confRoot) Rollback() error {
+	var latest gatedProvider
+	var committed uint64
+
+	for _, p := range c.providers {
+		g, ok := p.(gatedProvider)
+		if !ok {
+			continue
+		}
+
+		if seq := g.getCommitted(); seq > committed {
+			latest = g
+			committed = seq
+		}
+	}
+
+	if latest == nil || !latest.rollback() {
+		return errors.New("[confRoot::Rollback] there is no previous snapshot to roll back to")
+	}
+
+	c.notifyChanges()
+	return nil
+}
@@ -0,0 +1,170 @@
+package gconf
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestValidationAndRollback(t *testing.T) {
+	source := NewMapConfSource(map[string]interface{}{
+		"database": map[string]interface{}{"host": "db.local", "port": 5432},
+	}).SetValidator(func(conf Conf) error {
+		if conf.TryGetInt("database/port", 0) <= 0 {
+			return errors.New("the port must be positive")
+		}
+		return nil
+	})
+
+	root, err := NewConfBuilder().
+		Add(source).
+		AddValidator(func(conf Conf) error {
+			if conf.TryGetString("database/host", "") == "" {
+				return errors.New("the host is required")
+			}
+			return nil
+		}).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	var failures []error
+	root.OnReloadFailed(func(err error) {
+		failures = append(failures, err)
+	})
+
+	if err := source.Set("database/port", -1); err == nil {
+		t.Errorf("change rejected by the validator of the source, expected: an error, returned: nil")
+	}
+
+	if err := source.Set("database/host", ""); err == nil {
+		t.Errorf("change rejected by the validator of the root, expected: an error, returned: nil")
+	}
+
+	if port := root.TryGetInt("database/port", 0); port != 5432 {
+		t.Errorf("last-known-good value, expected: %d, returned: %d", 5432, port)
+	}
+
+	if host := root.TryGetString("database/host", ""); host != "db.local" {
+		t.Errorf("last-known-good value, expected: %s, returned: %s", "db.local", host)
+	}
+
+	if len(failures) != 2 {
+		t.Errorf("number of the failed reloads, expected: %d, returned: %d", 2, len(failures))
+	}
+
+	if err := source.Set("database/port", 6543); err != nil {
+		t.Fatalf("valid change returned an error: %s", err.Error())
+	}
+
+	if port := root.TryGetInt("database/port", 0); port != 6543 {
+		t.Errorf("value after the change, expected: %d, returned: %d", 6543, port)
+	}
+
+	if err := root.Rollback(); err != nil {
+		t.Fatalf("rollback returned an error: %s", err.Error())
+	}
+
+	if port := root.TryGetInt("database/port", 0); port != 5432 {
+		t.Errorf("value after the rollback, expected: %d, returned: %d", 5432, port)
+	}
+
+	if err := root.Rollback(); err == nil {
+		t.Errorf("second rollback, expected: an error, returned: nil")
+	}
+
+	_, err = NewConfBuilder().
+		Add(NewJsonConfSource([]byte(`{"database": {"port": 5432}}`))).
+		AddValidator(func(conf Conf) error {
+			return errors.New("always rejected")
+		}).
+		Build()
+
+	if err == nil {
+		t.Errorf("build of an invalid configuration, expected: an error, returned: nil")
+	}
+}
+
+func TestRollbackHistory(t *testing.T) {
+	first := NewMapConfSource(map[string]interface{}{"x": 1})
+	second := NewMapConfSource(map[string]interface{}{"y": 1})
+
+	root, err := NewConfBuilder().Add(first).Add(second).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	first.Set("x", 2)
+	second.Set("y", 2)
+	first.Set("x", 3)
+
+	if err := root.SetOverride("z", "override", "test"); err != nil {
+		t.Fatalf("can't set the override: %s", err.Error())
+	}
+
+	expected := []struct {
+		x int
+		y int
+	}{
+		{2, 2},
+		{2, 1},
+		{1, 1},
+	}
+
+	for i, e := range expected {
+		if err := root.Rollback(); err != nil {
+			t.Fatalf("rollback %d returned an error: %s", i, err.Error())
+		}
+
+		if x, y := root.TryGetInt("x", 0), root.TryGetInt("y", 0); x != e.x || y != e.y {
+			t.Errorf("values after the rollback %d, expected: %d, %d, returned: %d, %d", i, e.x, e.y, x, y)
+		}
+	}
+
+	if err := root.Rollback(); err == nil {
+		t.Errorf("rollback of the first load, expected: an error, returned: nil")
+	}
+
+	if z := root.TryGetString("z", ""); z != "override" {
+		t.Errorf("override after the rollbacks, expected: %s, returned: %s", "override", z)
+	}
+}
+
+func TestRejectedSetKeepsConcurrentSets(t *testing.T) {
+	source := NewMapConfSource(nil).SetValidator(func(conf Conf) error {
+		if conf.TryGetInt("invalid", 0) != 0 {
+			return errors.New("the key is rejected")
+		}
+		return nil
+	})
+
+	root, err := NewConfBuilder().Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+			source.Set(fmt.Sprintf("key%d", i), i)
+		}(i)
+
+		go func(i int) {
+			defer wg.Done()
+			source.Set("invalid", i+1)
+		}(i)
+	}
+
+	wg.Wait()
+
+	if keys := root.Keys(); len(keys) != 20 {
+		t.Errorf("keys after the sets, expected: 20, returned: %v", keys)
+	}
+}
@@ -0,0 +1,127 @@
+package gconf
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+const DefaultWatchBufferSize = 16
+
+type OverflowPolicy int
+
+const (
+	// OverflowDrop drops the changes which don't fit in the buffer of the channel.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock blocks the notifications until the subscriber receives the changes,
+	// the context is done or the root is disposed. The notifications of the root are delivered
+	// in order, so a slow receiver holds the other subscribers and watchers of the root as well.
+	OverflowBlock
+)
+
+type watchOptions struct {
+	bufferSize int
+	policy     OverflowPolicy
+}
+
+type WatchOption func(*watchOptions)
+
+// WithBufferSize sets the buffer size of the channel of the changes.
+func WithBufferSize(size int) WatchOption {
+	return func(o *watchOptions) {
+		if size >= 0 {
+			o.bufferSize = size
+		}
+	}
+}
+
+// WithOverflowPolicy sets what happens when the buffer of the channel is full.
+func WithOverflowPolicy(policy OverflowPolicy) WatchOption {
+	return func(o *watchOptions) {
+		o.policy = policy
+	}
+}
+
+type changeWatcher struct {
+	ctx     context.Context
+	done    chan struct{}
+	changes chan ConfChanges
+	policy  OverflowPolicy
+	closed  bool
+	mutex   sync.Mutex
+}
+
+// Watch streams the changes of the merged view under the prefix. The changes are delivered
+// in the order they happened, and the channel is closed when the context is done or the root is disposed.
+// The changes which don't fit in the buffer are dropped unless OverflowBlock is set.
+func (c *confRoot) Watch(ctx context.Context, prefix string, options ...WatchOption) <-chan ConfChanges {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	opts := watchOptions{
+		bufferSize: DefaultWatchBufferSize,
+		policy:     OverflowDrop,
+	}
+
+	for _, o := range options {
+		o(&opts)
+	}
+
+	w := &changeWatcher{
+		ctx:     ctx,
+		done:    c.done,
+		changes: make(chan ConfChanges, opts.bufferSize),
+		policy:  opts.policy,
+	}
+
+	id := c.subscribe(prefix, w.send)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.done:
+		}
+
+		c.unsubscribe(id)
+		w.close()
+	}()
+
+	return w.changes
+}
+
+func (w *changeWatcher) send(changes ConfChanges) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	if w.policy == OverflowDrop {
+		select {
+		case w.changes <- changes:
+		default:
+			log.Printf("the buffer of the watcher is full, %d changes are dropped\n", changes.GetNumOfChanges())
+		}
+		return
+	}
+
+	select {
+	case w.changes <- changes:
+	case <-w.ctx.Done():
+	case <-w.done:
+	}
+}
+
+func (w *changeWatcher) close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	w.closed = true
+	close(w.changes)
+}
@@ -0,0 +1,99 @@
+package gconf
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRootWatch(t *testing.T) {
+	source := NewMapConfSource(map[string]interface{}{
+		"database": map[string]interface{}{"port": 0},
+		"logging":  map[string]interface{}{"level": "info"},
+	})
+
+	root, err := NewConfBuilder().Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := root.Watch(ctx, "database", WithBufferSize(1), WithOverflowPolicy(OverflowBlock))
+	disposed := root.Watch(context.Background(), "")
+
+	go func() {
+		for i := 1; i <= 20; i++ {
+			source.Set("database/port", i)
+			source.Set("logging/level", i)
+		}
+	}()
+
+	last := 0
+	timeout := time.After(3 * time.Second)
+
+	for last < 20 {
+		select {
+		case c := <-changes:
+			for _, change := range c.GetChanges() {
+				if change.KeyName != "/database/port" {
+					t.Fatalf("change out of the prefix: %s", change.String())
+				}
+
+				port := change.Current.(int)
+				if port <= last {
+					t.Fatalf("changes out of order, expected: greater than %d, returned: %d", last, port)
+				}
+				last = port
+			}
+		case <-timeout:
+			t.Fatalf("the changes weren't delivered, last: %d", last)
+		}
+	}
+
+	cancel()
+	waitForClosed(t, changes)
+
+	root.Dispose()
+	waitForClosed(t, disposed)
+}
+
+func waitForClosed(t *testing.T, changes <-chan ConfChanges) {
+	timeout := time.After(time.Second)
+
+	for {
+		select {
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("the channel wasn't closed")
+		}
+	}
+}
+
+func TestRootWatchDropsByDefault(t *testing.T) {
+	source := NewMapConfSource(map[string]interface{}{"port": 0})
+
+	root, err := NewConfBuilder().Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+	defer root.Dispose()
+
+	// nobody receives from the watcher, it must not hold the other subscribers
+	root.Watch(context.Background(), "", WithBufferSize(1))
+
+	notified := make(chan ConfChanges, 10)
+	root.OnChange("", func(changes ConfChanges) { notified <- changes })
+
+	for i := 1; i <= 5; i++ {
+		source.Set("port", i)
+
+		select {
+		case <-notified:
+		case <-time.After(time.Second):
+			t.Fatalf("the change %d wasn't notified to the other subscriber", i)
+		}
+	}
+}
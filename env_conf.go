@@ -5,13 +5,31 @@ import (
 	"strings"
 )
 
+type EnvKeyCase int
+
+const (
+	// EnvKeyCaseAsIs keeps the names of the environment variables.
+	EnvKeyCaseAsIs EnvKeyCase = iota
+	// EnvKeyCaseLower converts the keys into lower case.
+	EnvKeyCaseLower
+	// EnvKeyCaseUpper converts the keys into upper case.
+	EnvKeyCaseUpper
+	// EnvKeyCaseMatchSources spells the keys the way the sources of the lower priority do
+	// in the merged view of the root, so LOGGING__PRINTLEVEL overrides /logging/printLevel.
+	EnvKeyCaseMatchSources
+)
+
 type EnvConfSource struct {
-	prefix string
+	prefix       string
+	separators   []string
+	keyCase      EnvKeyCase
+	keyTransform func(key string) string
 }
 
 func NewEnvConfSource() *EnvConfSource {
 	return &EnvConfSource{
-		prefix: "",
+		prefix:  "",
+		keyCase: EnvKeyCaseAsIs,
 	}
 }
 
@@ -21,29 +39,62 @@ func (s *EnvConfSource) Build(builder ConfBuilder) (ConfProvider, error) {
 
 func (s *EnvConfSource) Load() (map[string]interface{}, error) {
 	parser := newEnvConfParser(s.prefix)
+	parser.separators = s.separators
+	parser.keyCase = s.keyCase
+	parser.keyTransform = s.keyTransform
 	parser.Parse()
 
 	return parser.GetDataMap(), nil
 }
 
+// SetPrefix sets the prefix of the environment variables to load,
+// the prefix is removed from the keys.
 func (s *EnvConfSource) SetPrefix(prefix string) *EnvConfSource {
 	s.prefix = prefix
 	return s
 }
 
+// SetSeparators sets the separators of the path entities in the names, such as "__" for
+// LOGGING__PRINTLEVEL to /LOGGING/PRINTLEVEL. The numeric entities are mapped to
+// the array indexes, so SERVERS__0__HOST is mapped to /SERVERS/$0/HOST.
+func (s *EnvConfSource) SetSeparators(separators ...string) *EnvConfSource {
+	s.separators = separators
+	return s
+}
+
+func (s *EnvConfSource) SetKeyCase(keyCase EnvKeyCase) *EnvConfSource {
+	s.keyCase = keyCase
+	return s
+}
+
+// SetKeyTransform sets the hook which is called with every mapped key,
+// it returns the key to use or an empty string to skip the variable.
+func (s *EnvConfSource) SetKeyTransform(keyTransform func(key string) string) *EnvConfSource {
+	s.keyTransform = keyTransform
+	return s
+}
+
+func (s *EnvConfSource) followsKeyCase() bool {
+	return s.keyCase == EnvKeyCaseMatchSources
+}
+
 const (
 	envKeyValueDelimiter string = "="
 )
 
 type envConfParser struct {
-	dataMap map[string]interface{}
-	prefix  string
+	dataMap      map[string]interface{}
+	prefix       string
+	separators   []string
+	keyCase      EnvKeyCase
+	keyTransform func(key string) string
 }
 
 func newEnvConfParser(prefix string) *envConfParser {
 	return &envConfParser{
 		dataMap: make(map[string]interface{}),
 		prefix:  prefix,
+		keyCase: EnvKeyCaseAsIs,
 	}
 }
 
@@ -51,9 +102,9 @@ func (p *envConfParser) Parse() {
 	env := os.Environ()
 
 	for _, set := range env {
-		pair := strings.Split(set, envKeyValueDelimiter)
+		pair := strings.SplitN(set, envKeyValueDelimiter, 2)
 
-		if len(pair) == 0 {
+		if len(pair) != 2 || pair[0] == "" {
 			continue
 		}
 
@@ -61,32 +112,63 @@ func (p *envConfParser) Parse() {
 			continue
 		}
 
-		key := strings.Replace(pair[0], p.prefix, "", 1)
-		key = PathCombine(RootPath, key)
+		key := p.toKey(pair[0][len(p.prefix):])
 
-		var value string
-		if len(pair) > 2 {
-			value = p.combineValue(pair[1:])
-		} else {
-			value = pair[1]
+		if key == "" || key == RootPath {
+			continue
 		}
 
-		p.dataMap[key] = value
+		p.dataMap[key] = pair[1]
 	}
 }
 
-func (p *envConfParser) combineValue(values []string) string {
-	if values == nil || len(values) == 0 {
-		return ""
+func (p *envConfParser) toKey(name string) string {
+	for _, sep := range p.separators {
+		if sep == "" {
+			continue
+		}
+		name = strings.Replace(name, sep, PathDelimiter, -1)
+	}
+
+	entities := NewStringSplitter(name).Split(PathDelimiter, true)
+
+	for i, e := range entities {
+		if isDigits(e) {
+			entities[i] = ArrayDelimiter + e
+			continue
+		}
+
+		switch p.keyCase {
+		case EnvKeyCaseLower:
+			entities[i] = strings.ToLower(e)
+		case EnvKeyCaseUpper:
+			entities[i] = strings.ToUpper(e)
+		}
 	}
 
-	if len(values) == 1 {
-		return values[0]
+	key := PathCombine(append([]string{RootPath}, entities...)...)
+
+	if p.keyTransform != nil {
+		key = p.keyTransform(key)
 	}
 
-	return strings.Join(values, envKeyValueDelimiter)
+	return key
 }
 
 func (p *envConfParser) GetDataMap() map[string]interface{} {
 	return p.dataMap
 }
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,142 @@
+package gconf
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setTestEnv(t *testing.T, env map[string]string) {
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+
+	t.Cleanup(func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	})
+}
+
+func TestEnvConfParser(t *testing.T) {
+	setTestEnv(t, map[string]string{
+		"GCONFTEST_LOGGING__PRINTLEVEL": "debug",
+		"GCONFTEST_SERVERS__0__HOST":    "alpha",
+		"GCONFTEST_SERVERS__1__HOST":    "beta",
+		"GCONFTEST_Name":                "app",
+		"OTHER_GCONFTEST_X":             "x",
+	})
+
+	tests := []struct {
+		setup    func(*EnvConfSource)
+		expected map[string]interface{}
+	}{
+		{
+			func(*EnvConfSource) {},
+			map[string]interface{}{
+				"/LOGGING__PRINTLEVEL": "debug",
+				"/SERVERS__0__HOST":    "alpha",
+				"/SERVERS__1__HOST":    "beta",
+				"/Name":                "app",
+			},
+		},
+		{
+			func(s *EnvConfSource) { s.SetSeparators("__") },
+			map[string]interface{}{
+				"/LOGGING/PRINTLEVEL": "debug",
+				"/SERVERS/$0/HOST":    "alpha",
+				"/SERVERS/$1/HOST":    "beta",
+				"/Name":               "app",
+			},
+		},
+		{
+			func(s *EnvConfSource) { s.SetSeparators("__").SetKeyCase(EnvKeyCaseLower) },
+			map[string]interface{}{
+				"/logging/printlevel": "debug",
+				"/servers/$0/host":    "alpha",
+				"/servers/$1/host":    "beta",
+				"/name":               "app",
+			},
+		},
+		{
+			func(s *EnvConfSource) { s.SetSeparators("__").SetKeyCase(EnvKeyCaseUpper) },
+			map[string]interface{}{
+				"/LOGGING/PRINTLEVEL": "debug",
+				"/SERVERS/$0/HOST":    "alpha",
+				"/SERVERS/$1/HOST":    "beta",
+				"/NAME":               "app",
+			},
+		},
+		{
+			func(s *EnvConfSource) {
+				s.SetSeparators("__").SetKeyTransform(func(key string) string {
+					if strings.HasPrefix(key, "/SERVERS") {
+						return ""
+					}
+					return strings.Replace(key, "PRINTLEVEL", "level", 1)
+				})
+			},
+			map[string]interface{}{
+				"/LOGGING/level": "debug",
+				"/Name":          "app",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		source := NewEnvConfSource().SetPrefix("GCONFTEST_")
+		test.setup(source)
+
+		data, err := source.Load()
+		if err != nil {
+			t.Fatalf("can't load the environment variables %d: %s", i, err.Error())
+		}
+
+		if !reflect.DeepEqual(data, test.expected) {
+			t.Errorf("keys of the environment variables %d, expected: %v, returned: %v", i, test.expected, data)
+		}
+	}
+}
+
+func TestEnvKeyCaseMatchSources(t *testing.T) {
+	setTestEnv(t, map[string]string{
+		"GCONFTEST_LOGGING__PRINTLEVEL": "debug",
+		"GCONFTEST_LOGGING__FILE":       "app.log",
+		"GCONFTEST_DATABASE__HOST":      "db.prod",
+	})
+
+	source := NewMapConfSource(map[string]interface{}{
+		"logging": map[string]interface{}{"printLevel": "info"},
+	})
+
+	root, err := NewConfBuilder().
+		Add(source).
+		Add(NewEnvConfSource().SetPrefix("GCONFTEST_").SetSeparators("__").SetKeyCase(EnvKeyCaseMatchSources)).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	expected := map[string]string{
+		"logging/printLevel": "debug",
+		"logging/FILE":       "app.log",
+		"DATABASE/HOST":      "db.prod",
+	}
+
+	for key, value := range expected {
+		if returned := root.TryGetString(key, ""); returned != value {
+			t.Errorf("value of the key[%s], expected: %s, returned: %s", key, value, returned)
+		}
+	}
+
+	// the keys the other sources add later are matched as well
+	if err := source.Set("database", map[string]interface{}{"host": "db.local"}); err != nil {
+		t.Fatalf("can't set the key: %s", err.Error())
+	}
+
+	if host := root.TryGetString("database/host", ""); host != "db.prod" {
+		t.Errorf("value of the key added later, expected: %s, returned: %s", "db.prod", host)
+	}
+}
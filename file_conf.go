@@ -0,0 +1,477 @@
+package gconf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileState is the state of the contents of the file which was loaded or written by the source.
+type fileState struct {
+	exists bool
+	hash   [sha256.Size]byte
+}
+
+func newFileState(stream []byte) fileState {
+	return fileState{
+		exists: true,
+		hash:   sha256.Sum256(stream),
+	}
+}
+
+// GenericFileConfSource loads a configuration file with the parser of its format.
+// Unless the format or the parser is set explicitly, the parser is found by the
+// extension of the file in the registry of the builder.
+type GenericFileConfSource struct {
+	path                  string
+	endureIfNotExist      bool
+	onConfChangedCallback func(ConfChanges)
+	format                string
+	parser                ConfParser
+	registry              *ConfParserRegistry
+	watchMode             WatchMode
+	pollingInterval       time.Duration
+	pollingHash           bool
+	debounce              time.Duration
+	validator             ConfValidator
+	writeBack             bool
+	loaded                fileState
+	pendingLoaded         fileState
+	written               fileState
+	writtenData           map[string]interface{}
+	positions             map[string]KeyPosition
+	pendingPositions      map[string]KeyPosition
+	mutex                 sync.RWMutex
+}
+
+func NewFileConfSource(path string) *GenericFileConfSource {
+	return &GenericFileConfSource{
+		path:                  path,
+		endureIfNotExist:      false,
+		onConfChangedCallback: nil,
+		watchMode:             WatchModeFsnotify,
+		pollingInterval:       DefaultPollingInterval,
+		debounce:              DefaultDebounceWindow,
+	}
+}
+
+func (s *GenericFileConfSource) Build(builder ConfBuilder) (ConfProvider, error) {
+	if builder != nil {
+		s.mutex.Lock()
+		s.registry = builder.GetConfParserRegistry()
+		s.mutex.Unlock()
+	}
+
+	if _, err := s.GetParser(); err != nil {
+		return nil, err
+	}
+
+	return NewFileConfProvider(s)
+}
+
+func (s *GenericFileConfSource) Load() (map[string]interface{}, error) {
+	parser, err := s.GetParser()
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo := s.GetFileInfo()
+
+	var data map[string]interface{}
+
+	if !fileInfo.Exists() {
+		s.setPending(fileState{}, nil)
+
+		if s.endureIfNotExist {
+			return data, nil
+		} else {
+			return nil, errors.New(fmt.Sprintf("can't find the configuration file[%s]", fileInfo.GetPhysicalPath()))
+		}
+	}
+
+	stream, err := fileInfo.ReadAll()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("can't read the configuration file[%s], err: %s", fileInfo.GetPhysicalPath(), err.Error()))
+	}
+
+	state := newFileState(stream)
+
+	// the contents written by Save are not parsed again, so the watcher's echo of the write changes nothing
+	if written, ok := s.getWritten(state); ok {
+		s.mutex.Lock()
+		s.pendingLoaded = state
+		s.pendingPositions = s.positions
+		s.mutex.Unlock()
+
+		return written, nil
+	}
+
+	if stream == nil || len(stream) == 0 {
+		s.setPending(state, nil)
+		return data, nil
+	}
+
+	data, err = parser.Parse(stream)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("can't parse the configuration file[%s], err: %s", fileInfo.GetPhysicalPath(), err.Error()))
+	}
+
+	s.setPending(state, scanKeyPositions(parser, stream))
+
+	return data, nil
+}
+
+// scanKeyPositions returns the positions of the keys, nil when the parser can't find them.
+func scanKeyPositions(parser ConfParser, stream []byte) map[string]KeyPosition {
+	scanner, ok := parser.(KeyPositionScanner)
+	if !ok {
+		return nil
+	}
+
+	positions, err := scanner.ScanKeyPositions(stream)
+	if err != nil {
+		return nil
+	}
+
+	return positions
+}
+
+func (s *GenericFileConfSource) setPending(state fileState, positions map[string]KeyPosition) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pendingLoaded = state
+	s.pendingPositions = positions
+}
+
+// commitLoad is called by the provider when it committed the contents of the last load. The state
+// of the file and the positions of the keys are kept until then, so they always describe the committed
+// contents, and Save refuses to overwrite the contents which were loaded but rejected.
+func (s *GenericFileConfSource) commitLoad() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.loaded = s.pendingLoaded
+	s.positions = s.pendingPositions
+}
+
+func (s *GenericFileConfSource) getWritten(state fileState) (map[string]interface{}, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.writtenData == nil || s.written != state {
+		return nil, false
+	}
+
+	return s.writtenData, true
+}
+
+// Save writes the flattened data into the file in its format and returns the data as it will be loaded
+// from the file. It refuses to write when the file has changed on disk since it was loaded, and the
+// file is replaced through a temporary file so the readers never see it half written.
+func (s *GenericFileConfSource) Save(data map[string]interface{}) (map[string]interface{}, error) {
+	parser, err := s.GetParser()
+	if err != nil {
+		return nil, err
+	}
+
+	encoder, ok := parser.(ConfEncoder)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("the parser of the configuration file[%s] can't encode the contents", s.path))
+	}
+
+	current := fileState{}
+	original, err := ioutil.ReadFile(s.path)
+
+	if err == nil {
+		current = newFileState(original)
+	} else if !os.IsNotExist(err) {
+		return nil, errors.New(fmt.Sprintf("can't read the configuration file[%s], err: %s", s.path, err.Error()))
+	}
+
+	s.mutex.RLock()
+	loaded := s.loaded
+	s.mutex.RUnlock()
+
+	if current != loaded {
+		return nil, errors.New(fmt.Sprintf("the configuration file[%s] has changed on disk since it was loaded", s.path))
+	}
+
+	stream, err := encodeFileContents(encoder, original, data)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("can't encode the configuration file[%s], err: %s", s.path, err.Error()))
+	}
+
+	saved, err := parser.Parse(stream)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("can't parse the encoded configuration file[%s], err: %s", s.path, err.Error()))
+	}
+
+	if err := replaceFile(s.path, stream); err != nil {
+		return nil, errors.New(fmt.Sprintf("can't write the configuration file[%s], err: %s", s.path, err.Error()))
+	}
+
+	state := newFileState(stream)
+
+	positions := scanKeyPositions(parser, stream)
+
+	s.mutex.Lock()
+	s.loaded = state
+	s.pendingLoaded = state
+	s.written = state
+	s.writtenData = saved
+	s.positions = positions
+	s.pendingPositions = positions
+	s.mutex.Unlock()
+
+	return saved, nil
+}
+
+// encodeFileContents updates the original contents when the encoder can patch them,
+// otherwise the whole document is encoded from the data.
+func encodeFileContents(encoder ConfEncoder, original []byte, data map[string]interface{}) ([]byte, error) {
+	patcher, ok := encoder.(ConfPatcher)
+
+	if !ok || len(original) == 0 {
+		return encoder.Encode(data)
+	}
+
+	stream, err := patcher.Patch(original, data)
+	if err != nil {
+		log.Printf("can't patch the configuration file, the whole file is encoded, err: %s\n", err.Error())
+		return encoder.Encode(data)
+	}
+
+	return stream, nil
+}
+
+// replaceFile writes the file into a temporary file of the same directory, flushes it
+// and renames it over the file.
+func replaceFile(path string, stream []byte) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+name+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = bytes.NewReader(stream).WriteTo(tmp)
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	// the rename is durable only when the directory is flushed, which isn't supported on every platform
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
+
+// SetFormat overrides the format detected from the extension of the file,
+// the format is either an extension such as "yaml" or a MIME type such as "application/json".
+func (s *GenericFileConfSource) SetFormat(format string) *GenericFileConfSource {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.format = format
+	return s
+}
+
+// SetParser sets the parser of the file, it takes precedence over the format.
+func (s *GenericFileConfSource) SetParser(parser ConfParser) *GenericFileConfSource {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.parser = parser
+	return s
+}
+
+func (s *GenericFileConfSource) GetFormat() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.format
+}
+
+func (s *GenericFileConfSource) getConfParserRegistry() *ConfParserRegistry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.registry
+}
+
+// GetParser returns the parser set explicitly, or the parser of the format found in the registry.
+func (s *GenericFileConfSource) GetParser() (ConfParser, error) {
+	s.mutex.RLock()
+	parser, format, registry := s.parser, s.format, s.registry
+	s.mutex.RUnlock()
+
+	if parser != nil {
+		return parser, nil
+	}
+
+	if registry == nil {
+		registry = defaultConfParserRegistry
+	}
+
+	var ok bool
+
+	if format != "" {
+		parser, ok = registry.GetParser(format)
+	} else {
+		parser, ok = registry.GetParserByPath(s.path)
+	}
+
+	if !ok {
+		if format == "" {
+			return nil, errors.New(fmt.Sprintf("can't find the parser of the configuration file[%s]", s.path))
+		}
+
+		return nil, errors.New(fmt.Sprintf("can't find the parser of the format[%s] for the configuration file[%s]", format, s.path))
+	}
+
+	return parser, nil
+}
+
+func (s *GenericFileConfSource) SetEndureIfNotExist(endureIfNotExist bool) FileConfSource {
+	s.endureIfNotExist = endureIfNotExist
+	return s
+}
+
+func (s *GenericFileConfSource) SetOnConfChangedCallback(onConfChangedCallback func(ConfChanges)) FileConfSource {
+	s.onConfChangedCallback = onConfChangedCallback
+	return s
+}
+
+func (s *GenericFileConfSource) GetOnConfChangedCallback() func(ConfChanges) {
+	return s.onConfChangedCallback
+}
+
+func (s *GenericFileConfSource) SetWatchMode(mode WatchMode) FileConfSource {
+	s.watchMode = mode
+	return s
+}
+
+func (s *GenericFileConfSource) GetWatchMode() WatchMode {
+	return s.watchMode
+}
+
+func (s *GenericFileConfSource) SetPollingInterval(interval time.Duration) FileConfSource {
+	s.pollingInterval = interval
+	return s
+}
+
+func (s *GenericFileConfSource) GetPollingInterval() time.Duration {
+	return s.pollingInterval
+}
+
+// SetPollingHash makes the polling compare the hash of the contents as well as the modification time and size.
+func (s *GenericFileConfSource) SetPollingHash(compareHash bool) FileConfSource {
+	s.pollingHash = compareHash
+	return s
+}
+
+func (s *GenericFileConfSource) IsPollingHash() bool {
+	return s.pollingHash
+}
+
+// SetDebounce sets the window in which the bursts of the file events are coalesced into one reload,
+// the file is reloaded on every event when the window is zero.
+func (s *GenericFileConfSource) SetDebounce(window time.Duration) FileConfSource {
+	s.debounce = window
+	return s
+}
+
+func (s *GenericFileConfSource) GetDebounce() time.Duration {
+	return s.debounce
+}
+
+// SetValidator sets the validator of the contents of the file, the contents it rejects aren't applied.
+func (s *GenericFileConfSource) SetValidator(validator ConfValidator) FileConfSource {
+	s.validator = validator
+	return s
+}
+
+func (s *GenericFileConfSource) GetValidator() ConfValidator {
+	return s.validator
+}
+
+// SetWriteBack makes the provider write the values set on it into the file.
+func (s *GenericFileConfSource) SetWriteBack(writeBack bool) FileConfSource {
+	s.writeBack = writeBack
+	return s
+}
+
+func (s *GenericFileConfSource) IsWriteBack() bool {
+	return s.writeBack
+}
+
+func (s *GenericFileConfSource) GetFileInfo() FileInfo {
+	return NewFileInfo(s.path)
+}
+
+func (s *GenericFileConfSource) GetFilePath() string {
+	return s.path
+}
+
+func (s *GenericFileConfSource) IsEndureIfNotExist() bool {
+	return s.endureIfNotExist
+}
+
+func (s *GenericFileConfSource) IsFileExist() bool {
+	return s.GetFileInfo().Exists()
+}
+
+// LocateKey finds the position of the key in the contents which were loaded last, the positions
+// are found when the file is loaded by a parser which implements KeyPositionScanner.
+func (s *GenericFileConfSource) LocateKey(key string) (KeyPosition, error) {
+	s.mutex.RLock()
+	positions := s.positions
+	s.mutex.RUnlock()
+
+	if positions == nil {
+		return KeyPosition{}, errors.New(fmt.Sprintf("the positions of the keys of the configuration file[%s] are unknown", s.path))
+	}
+
+	pos, ok := locateKey(positions, key)
+	if !ok {
+		return KeyPosition{}, errors.New(fmt.Sprintf("can't find the position of the key[%s]", key))
+	}
+
+	return pos, nil
+}
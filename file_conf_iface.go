@@ -23,8 +23,36 @@ type FileConfSource interface {
 	GetFilePath() string
 	IsEndureIfNotExist() bool
 	IsFileExist() bool
+	SetWatchMode(mode WatchMode) FileConfSource
+	GetWatchMode() WatchMode
+	SetPollingInterval(interval time.Duration) FileConfSource
+	GetPollingInterval() time.Duration
+	SetPollingHash(compareHash bool) FileConfSource
+	IsPollingHash() bool
+	SetDebounce(window time.Duration) FileConfSource
+	GetDebounce() time.Duration
+	SetValidator(validator ConfValidator) FileConfSource
+	GetValidator() ConfValidator
+	SetWriteBack(writeBack bool) FileConfSource
+	IsWriteBack() bool
 }
 
+// FileConfWriter is implemented by the file sources which can write the contents back into the file.
+type FileConfWriter interface {
+	Save(data map[string]interface{}) (map[string]interface{}, error)
+}
+
+type WatchMode int
+
+const (
+	// WatchModeFsnotify watches the file with the events of the filesystem.
+	WatchModeFsnotify WatchMode = iota
+	// WatchModePolling compares the state of the file on every polling interval.
+	WatchModePolling
+	// WatchModeFsnotifyWithPollingFallback polls the file when the filesystem can't be watched.
+	WatchModeFsnotifyWithPollingFallback
+)
+
 type FileConfProvider interface {
 	ConfProvider
 	OnChanged()
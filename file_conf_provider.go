@@ -2,25 +2,43 @@ package gconf
 
 import (
 	"errors"
-	"log"
 	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultDebounceWindow = 100 * time.Millisecond
+	// maxReloadRetries is the number of the retries of the reload which failed,
+	// usually because the file was read while it was being written.
+	maxReloadRetries = 3
 )
 
 type fileConfProvider struct {
-	path        string
-	data        map[string]interface{}
-	source      FileConfSource
-	converter   TypeConverter
-	changeToken ChangeToken
-	fileWatcher Watcher
+	path                string
+	store               *confStore
+	source              FileConfSource
+	converter           TypeConverter
+	changeToken         ChangeToken
+	fileWatcher         Watcher
+	callbacks           []func(ConfChanges)
+	debouncer           *time.Timer
+	retries             int
+	disposed            bool
+	gate                func(ConfProvider, map[string]interface{}) error
+	reloadFailedHandler func(error)
+	writeMutex          sync.Mutex
+	mutex               sync.Mutex
 }
 
 func NewFileConfProvider(source FileConfSource) (FileConfProvider, error) {
 	p := &fileConfProvider{
 		path:        RootPath,
 		source:      source,
-		changeToken: nil,
-		data:        make(map[string]interface{}),
+		changeToken: NewChangeToken(),
+		store:       newConfStore(),
 	}
 
 	p.converter = NewTypeConverter(p)
@@ -29,44 +47,83 @@ func NewFileConfProvider(source FileConfSource) (FileConfProvider, error) {
 		return nil, err
 	}
 
+	p.mutex.Lock()
 	p.bindFileWatcher()
+	p.mutex.Unlock()
 
 	return p, nil
 }
 
+// bindFileWatcher must be called with the mutex held.
 func (c *fileConfProvider) bindFileWatcher() {
 	if c.fileWatcher != nil {
 		c.fileWatcher.Close()
 		c.fileWatcher = nil
 	}
 
-	c.changeToken = NewChangeToken()
-
-	if c.source.GetOnConfChangedCallback() == nil {
+	if c.source.GetOnConfChangedCallback() == nil && len(c.callbacks) == 0 {
 		return
 	}
 
-	c.changeToken.SetCallback(c.OnChanged)
-	watcher, err := NewFileWatcher(c.source.GetFilePath())
+	watcher, err := newSourceWatcher(c.source, newSignalToken(c.onFileChanged))
 
 	if err != nil {
 		log.Println("can't start the filewatcher: " + err.Error())
 	} else {
 		c.fileWatcher = watcher
-		c.fileWatcher.Watch(c.changeToken)
 	}
 }
 
+// newSourceWatcher starts watching the file of the source in its watch mode.
+func newSourceWatcher(source FileConfSource, signal ChangeToken) (Watcher, error) {
+	filePath := source.GetFilePath()
+
+	if source.GetWatchMode() != WatchModePolling {
+		watcher, err := NewFileWatcher(filePath)
+
+		if err == nil {
+			err = watcher.Watch(signal)
+		}
+
+		if err == nil {
+			return watcher, nil
+		}
+
+		if source.GetWatchMode() != WatchModeFsnotifyWithPollingFallback {
+			return nil, err
+		}
+
+		log.Printf("can't watch the file[%s], falling back to polling: %s\n", filePath, err.Error())
+	}
+
+	watcher, err := NewPollingWatcher(filePath, source.GetPollingInterval())
+	if err != nil {
+		return nil, err
+	}
+
+	watcher.SetCompareHash(source.IsPollingHash())
+
+	if err := watcher.Watch(signal); err != nil {
+		return nil, err
+	}
+
+	return watcher, nil
+}
+
 func (c *fileConfProvider) GetPath() string {
 	return c.path
 }
 
+func (c *fileConfProvider) getConfParserRegistry() *ConfParserRegistry {
+	return confParserRegistryOf(c.source)
+}
+
 func (c *fileConfProvider) Get(key string) interface{} {
 	if key == "" {
 		return nil
 	}
 
-	value, exist := c.data[key]
+	value, exist := c.store.get(key)
 
 	if exist == false {
 		return nil
@@ -75,64 +132,114 @@ func (c *fileConfProvider) Get(key string) interface{} {
 	return value
 }
 
+// Set sets the value in memory, or writes it into the file as well when the source is write-back.
+// The value set in memory only is replaced when the file is reloaded.
 func (c *fileConfProvider) Set(key string, value interface{}) error {
 	if key == "" {
 		return errors.New("[FileConfProvider::Set] invalid null argument: key")
 	}
 
-	c.data[key] = value
+	writer, ok := c.source.(FileConfWriter)
+
+	if !ok || !c.source.IsWriteBack() {
+		c.notify(c.store.set(key, value))
+		return nil
+	}
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	data := copyMap(c.store.snapshot())
+	data[normalizeKey(key)] = value
+
+	if err := c.validate(data); err != nil {
+		return err
+	}
+
+	saved, err := writer.Save(data)
+	if err != nil {
+		return errors.New(fmt.Sprintf("[FileConfProvider::Set] can't write the value of the key[%s]: %s", key, err.Error()))
+	}
+
+	c.notify(c.store.commit(saved))
 	return nil
 }
 
-func (c *fileConfProvider) ContainKey(key string) bool {
+// Delete removes the value of the key, an element of an array is removed with the following elements shifted down.
+// When the source writes back, the file is updated before the deletion is committed. Otherwise the deletion
+// only changes the memory like Set, and the key comes back when the file is reloaded.
+func (c *fileConfProvider) Delete(key string) error {
+	return c.delete(key, false)
+}
+
+// DeleteSection removes the key and everything under it.
+func (c *fileConfProvider) DeleteSection(key string) error {
+	return c.delete(key, true)
+}
+
+func (c *fileConfProvider) delete(key string, section bool) error {
 	if key == "" {
-		return false
+		return errors.New("[FileConfProvider::Delete] invalid null argument: key")
 	}
 
-	_, exist := c.data[key]
+	writer, ok := c.source.(FileConfWriter)
 
-	return exist
-}
+	if !ok || !c.source.IsWriteBack() {
+		changes, removed := c.store.remove(key, section)
+		if !removed {
+			return errors.New(fmt.Sprintf("[FileConfProvider::Delete] the key doesn't exist: %s", key))
+		}
 
-func (c *fileConfProvider) Keys() []string {
+		c.notify(changes)
+		return nil
+	}
 
-	var keys []string
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
 
-	for k := range c.data {
-		keys = append(keys, k)
-	}
+	data := copyMap(c.store.snapshot())
 
-	return keys
-}
+	if !removeKeys(data, key, section) {
+		return errors.New(fmt.Sprintf("[FileConfProvider::Delete] the key doesn't exist: %s", key))
+	}
 
-func (c *fileConfProvider) Values() []interface{} {
-	var values []interface{}
+	if err := c.validate(data); err != nil {
+		return err
+	}
 
-	for _, v := range c.data {
-		values = append(values, v)
+	saved, err := writer.Save(data)
+	if err != nil {
+		return errors.New(fmt.Sprintf("[FileConfProvider::Delete] can't delete the key[%s] from the file: %s", key, err.Error()))
 	}
 
-	return values
+	c.notify(c.store.commit(saved))
+	return nil
 }
 
-func (c *fileConfProvider) ToKeyValuePairs() []KeyValuePair {
-	var pairs []KeyValuePair
+func (c *fileConfProvider) ContainKey(key string) bool {
+	if key == "" {
+		return false
+	}
 
-	for k, v := range c.data {
+	_, exist := c.store.get(key)
 
-		pair := KeyValuePair{
-			Key:   k,
-			Value: v,
-		}
+	return exist
+}
 
-		pairs = append(pairs, pair)
-	}
+func (c *fileConfProvider) Keys() []string {
+	return c.store.keys()
+}
 
-	return pairs
+func (c *fileConfProvider) Values() []interface{} {
+	return c.store.values()
+}
+
+func (c *fileConfProvider) ToKeyValuePairs() []KeyValuePair {
+	return c.store.pairs()
 }
 
 func (c *fileConfProvider) IsEmpty() bool {
-	return len(c.data) == 0
+	return c.store.isEmpty()
 }
 
 func (c *fileConfProvider) IsArray() bool {
@@ -183,6 +290,10 @@ func (c *fileConfProvider) GetString(key string) (string, error) {
 	return c.converter.GetString(key)
 }
 
+func (c *fileConfProvider) GetValue(key string) (interface{}, error) {
+	return c.converter.GetValue(key)
+}
+
 func (c *fileConfProvider) TryGetInt(key string, defaultValue int) int {
 	return c.converter.TryGetInt(key, defaultValue)
 }
@@ -245,15 +356,34 @@ func (c *fileConfProvider) GetArraySection(key string) ConfArraySection {
 	return NewConfArraySection(c, PathCombine(c.path, key))
 }
 
+func (c *fileConfProvider) Unmarshal(key string, out interface{}) error {
+	return c.converter.Unmarshal(key, out)
+}
+
+func (c *fileConfProvider) Dump(w io.Writer, format string, options ...DumpOption) error {
+	return c.converter.Dump(w, format, options...)
+}
+
+func (c *fileConfProvider) Query(pattern string) ([]KeyValuePair, error) {
+	return c.converter.Query(pattern)
+}
+
 func (c *fileConfProvider) Reload() error {
 	return c.Load()
 }
 
+// GetChangeToken returns the token of the next change, a fresh token is issued on every change.
 func (c *fileConfProvider) GetChangeToken() ChangeToken {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	return c.changeToken
 }
 
 func (c *fileConfProvider) Load() error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
 	isExist := c.source.IsFileExist()
 	filePath := c.source.GetFilePath()
 
@@ -263,7 +393,7 @@ func (c *fileConfProvider) Load() error {
 
 	if !isExist {
 		log.Printf("can't find the configuration file: %s\n", filePath)
-		c.data = make(map[string]interface{})
+		c.store.commit(nil)
 		return nil
 	}
 
@@ -272,33 +402,212 @@ func (c *fileConfProvider) Load() error {
 		return errors.New(fmt.Sprintf("can't load the configuration file[%s], err: %s\n", filePath, err.Error()))
 	}
 
-	c.data = data
+	if err := c.validate(data); err != nil {
+		return err
+	}
+
+	c.store.commit(data)
+	c.commitLoad()
 	return nil
 }
 
+func (c *fileConfProvider) commitLoad() {
+	if l, ok := c.source.(loadCommitter); ok {
+		l.commitLoad()
+	}
+}
+
+func (c *fileConfProvider) validate(data map[string]interface{}) error {
+	c.mutex.Lock()
+	gate := c.gate
+	c.mutex.Unlock()
+
+	return validateContents(c, c.source, gate, data)
+}
+
+// onFileChanged coalesces the events of the file raised in the debounce window into one reload.
+func (c *fileConfProvider) onFileChanged() {
+	window := c.source.GetDebounce()
+
+	if window <= 0 {
+		c.OnChanged()
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.disposed {
+		return
+	}
+
+	c.retries = 0
+	c.scheduleReload(window)
+}
+
+// scheduleReload must be called with the mutex held.
+func (c *fileConfProvider) scheduleReload(window time.Duration) {
+	if c.debouncer != nil {
+		c.debouncer.Stop()
+	}
+
+	c.debouncer = time.AfterFunc(window, c.reloadDebounced)
+}
+
+func (c *fileConfProvider) reloadDebounced() {
+	err := c.reload()
+
+	if err == nil || (!isValidationError(err) && c.retryReload()) {
+		return
+	}
+
+	log.Println(err.Error())
+	c.fireReloadFailed(err)
+}
+
+// retryReload schedules the reload again after the debounce window,
+// unless it has been retried enough or the provider is disposed.
+func (c *fileConfProvider) retryReload() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.disposed || c.retries >= maxReloadRetries {
+		c.retries = 0
+		return false
+	}
+
+	c.retries++
+	c.scheduleReload(c.source.GetDebounce())
+
+	return true
+}
+
 func (c *fileConfProvider) OnChanged() {
+	if err := c.reload(); err != nil {
+		log.Println(err.Error())
+		c.fireReloadFailed(err)
+	}
+}
+
+func (c *fileConfProvider) fireReloadFailed(err error) {
+	c.mutex.Lock()
+	handler := c.reloadFailedHandler
+	c.mutex.Unlock()
+
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// reload reloads the file, the last-known-good contents are kept
+// when the file can't be loaded or is rejected by a validator.
+func (c *fileConfProvider) reload() error {
+	// the writes of the values and the reloads compare and update the loaded state of the file in turn
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
 
 	changedData, err := c.source.Load()
 
 	if err != nil {
-		log.Println(err.Error())
-		return
+		return err
+	}
+
+	if err := c.validate(changedData); err != nil {
+		return err
 	}
 
-	changes := CalcConfChanges(changedData, c.data)
+	changes := c.store.commit(changedData)
+	c.commitLoad()
+
+	c.notify(changes)
+	return nil
+}
 
+func (c *fileConfProvider) notify(changes ConfChanges) {
 	if changes.GetNumOfChanges() == 0 {
-		log.Println("there is no changes in file configuration provider")
 		return
 	}
 
-	c.data = changedData
-	c.bindFileWatcher()
+	c.mutex.Lock()
+	token := c.changeToken
+	c.changeToken = NewChangeToken()
+	callbacks := c.callbacks
+	c.mutex.Unlock()
+
+	token.OnChanged()
+
+	if callback := c.source.GetOnConfChangedCallback(); callback != nil {
+		go callback(changes)
+	}
+
+	for _, callback := range callbacks {
+		go callback(changes)
+	}
+}
+
+func (c *fileConfProvider) AddOnConfChangedCallback(callback func(ConfChanges)) {
+	if callback == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.callbacks = append(c.callbacks, callback)
+
+	if c.fileWatcher == nil {
+		c.bindFileWatcher()
+	}
+}
+
+func (c *fileConfProvider) setGate(gate func(ConfProvider, map[string]interface{}) error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.gate = gate
+}
+
+func (c *fileConfProvider) setReloadFailedHandler(handler func(error)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.reloadFailedHandler = handler
+}
+
+func (c *fileConfProvider) getCommitted() uint64 {
+	return c.store.getCommitted()
+}
+
+func (c *fileConfProvider) rollback() bool {
+	changes, ok := c.store.rollback()
+
+	if ok {
+		c.notify(changes)
+	}
 
-	go c.source.GetOnConfChangedCallback()(changes)
+	return ok
+}
+
+func (c *fileConfProvider) getVersion() uint64 {
+	return c.store.getVersion()
+}
+
+func (c *fileConfProvider) getSource() ConfSource {
+	return c.source
 }
 
 func (c *fileConfProvider) Dispose() {
-	c.fileWatcher.Close()
-	c.data = nil
+	c.mutex.Lock()
+	if c.fileWatcher != nil {
+		c.fileWatcher.Close()
+		c.fileWatcher = nil
+	}
+	if c.debouncer != nil {
+		c.debouncer.Stop()
+		c.debouncer = nil
+	}
+	c.disposed = true
+	c.mutex.Unlock()
+
+	c.store.swap(nil)
 }
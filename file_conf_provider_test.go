@@ -0,0 +1,80 @@
+package gconf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileConfProviderDebounce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"version": "0"}`)
+
+	var reloads int32
+
+	root, err := NewConfBuilder().
+		Add(NewFileConfSource(path).
+			SetDebounce(200 * time.Millisecond).
+			SetOnConfChangedCallback(func(ConfChanges) { atomic.AddInt32(&reloads, 1) })).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+	defer root.Dispose()
+
+	for i := 1; i <= 10; i++ {
+		writeFileAtomic(t, path, fmt.Sprintf(`{"version": "%d"}`, i))
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	waitForValue(t, root, "version", "10")
+	time.Sleep(300 * time.Millisecond)
+
+	if returned := atomic.LoadInt32(&reloads); returned != 1 {
+		t.Errorf("number of the reloads of a burst, expected: %d, returned: %d", 1, returned)
+	}
+}
+
+func TestFileConfProviderRetryPartialRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"version": "1"}`)
+
+	provider, err := NewFileConfProvider(NewFileConfSource(path).SetDebounce(200 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("can't create the provider: %s", err.Error())
+	}
+	defer provider.Dispose()
+
+	// the file is read while it's half written, then completed after the first reload failed
+	writeFileAtomic(t, path, `{"version": `)
+	provider.(*fileConfProvider).onFileChanged()
+
+	time.Sleep(300 * time.Millisecond)
+	writeFileAtomic(t, path, `{"version": "2"}`)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for provider.TryGetString("version", "") != "2" && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if returned := provider.TryGetString("version", ""); returned != "2" {
+		t.Errorf("value after the retry, expected: %s, returned: %s", "2", returned)
+	}
+}
@@ -0,0 +1,266 @@
+package gconf
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileConfSourceFormatDetection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"base.json":  `{ "name": "json" }`,
+		"app.yml":    "yaml:\n  name: yaml\n",
+		"db.toml":    "[[servers]]\nhost = \"alpha\"\n\n[[servers]]\nhost = \"beta\"\n",
+		"extra.conf": "conf.name=custom\n",
+		"data.txt":   `{ "mime": "json" }`,
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("can't write the file[%s]: %s", name, err.Error())
+		}
+	}
+
+	properties := ConfParserFunc(func(stream []byte) (map[string]interface{}, error) {
+		data := make(map[string]interface{})
+
+		for _, line := range strings.Split(string(stream), "\n") {
+			if kv := strings.SplitN(line, "=", 2); len(kv) == 2 {
+				data[PathCombine(RootPath, strings.Replace(kv[0], ".", PathDelimiter, -1))] = kv[1]
+			}
+		}
+
+		return data, nil
+	})
+
+	conf, err := NewConfBuilder().
+		RegisterConfParser(properties, ".conf").
+		Add(NewFileConfSource(filepath.Join(dir, "base.json"))).
+		Add(NewFileConfSource(filepath.Join(dir, "app.yml"))).
+		Add(NewFileConfSource(filepath.Join(dir, "db.toml"))).
+		Add(NewFileConfSource(filepath.Join(dir, "extra.conf"))).
+		Add(NewFileConfSource(filepath.Join(dir, "data.txt")).SetFormat("application/json; charset=utf-8")).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	expectations := map[string]string{
+		"name":            "json",
+		"yaml/name":       "yaml",
+		"servers/$0/host": "alpha",
+		"servers/$1/host": "beta",
+		"conf/name":       "custom",
+		"mime":            "json",
+	}
+
+	for key, expected := range expectations {
+		if returned := conf.TryGetString(key, ""); returned != expected {
+			t.Errorf("value of %s, expected: %s, returned: %s", key, expected, returned)
+		}
+	}
+
+	if _, err := NewConfBuilder().Add(NewFileConfSource(filepath.Join(dir, "extra.conf"))).Build(); err == nil {
+		t.Errorf("building a file of an unregistered format, expected: an error, returned: nil")
+	}
+}
+
+func TestFileConfSourceWriteBack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"app.json": `{"database": {"host": "db.local", "port": 5432}, "servers": ["alpha", "beta"]}`,
+		"app.yml":  "database:\n  host: db.local\n  port: 5432\nservers:\n  - alpha\n  - beta\n",
+		"app.toml": "servers = [\"alpha\", \"beta\"]\n\n[database]\nhost = \"db.local\"\nport = 5432\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		writeFileAtomic(t, path, content)
+
+		root, err := NewConfBuilder().
+			Add(NewFileConfSource(path).SetWriteBack(true).SetDebounce(10 * time.Millisecond)).
+			Build()
+
+		if err != nil {
+			t.Fatalf("can't build the configuration of %s: %s", name, err.Error())
+		}
+
+		var notifications int32
+		root.OnChange("", func(ConfChanges) { atomic.AddInt32(&notifications, 1) })
+
+		if err := root.Set("database/port", 6543); err != nil {
+			t.Fatalf("can't write the value into %s: %s", name, err.Error())
+		}
+
+		time.Sleep(200 * time.Millisecond)
+
+		if returned := atomic.LoadInt32(&notifications); returned != 1 {
+			t.Errorf("notifications of the write into %s, expected: %d, returned: %d", name, 1, returned)
+		}
+
+		root.Dispose()
+
+		reloaded, err := NewConfBuilder().Add(NewFileConfSource(path)).Build()
+		if err != nil {
+			t.Fatalf("can't load the written file %s: %s", name, err.Error())
+		}
+
+		expectations := map[string]string{
+			"database/host": "db.local",
+			"database/port": "6543",
+			"servers/$1":    "beta",
+		}
+
+		for key, expected := range expectations {
+			if returned := reloaded.TryGetString(key, ""); returned != expected {
+				t.Errorf("value of %s in %s, expected: %s, returned: %s", key, name, expected, returned)
+			}
+		}
+	}
+
+	path := filepath.Join(dir, "app.json")
+
+	provider, err := NewFileConfProvider(NewFileConfSource(path).SetWriteBack(true))
+	if err != nil {
+		t.Fatalf("can't create the provider: %s", err.Error())
+	}
+	defer provider.Dispose()
+
+	writeFileAtomic(t, path, `{"database": {"host": "changed.local"}}`)
+
+	if err := provider.Set("database/port", 1); err == nil {
+		t.Errorf("write into a file changed on disk, expected: an error, returned: nil")
+	}
+}
+
+func TestFileConfSourceRejectedReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"port": 5432}`)
+
+	source := NewFileConfSource(path).SetWriteBack(true).SetValidator(func(conf Conf) error {
+		if conf.TryGetInt("port", 0) <= 0 {
+			return errors.New("the port must be positive")
+		}
+		return nil
+	})
+
+	provider, err := NewFileConfProvider(source)
+	if err != nil {
+		t.Fatalf("can't create the provider: %s", err.Error())
+	}
+	defer provider.Dispose()
+
+	writeFileAtomic(t, path, `{"port": -1}`)
+
+	if err := provider.Reload(); err == nil {
+		t.Fatalf("reload of the rejected contents, expected: an error, returned: nil")
+	}
+
+	// the rejected contents aren't the loaded ones, so they aren't overwritten
+	if err := provider.Set("host", "db.local"); err == nil {
+		t.Errorf("write over the rejected contents, expected: an error, returned: nil")
+	}
+
+	writeFileAtomic(t, path, `{"port": 6543}`)
+
+	if err := provider.Reload(); err != nil {
+		t.Fatalf("can't reload the file: %s", err.Error())
+	}
+
+	if err := provider.Set("host", "db.local"); err != nil {
+		t.Errorf("can't write the value after the reload: %s", err.Error())
+	}
+}
+
+func TestFileConfProviderSetNotifies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"port": 5432, "host": "db.local"}`)
+
+	provider, err := NewFileConfProvider(NewFileConfSource(path))
+	if err != nil {
+		t.Fatalf("can't create the provider: %s", err.Error())
+	}
+	defer provider.Dispose()
+
+	changes := make(chan ConfChanges, 10)
+	provider.(ConfChangeNotifier).AddOnConfChangedCallback(func(c ConfChanges) { changes <- c })
+
+	if err := provider.Set("port", 6543); err != nil {
+		t.Fatalf("can't set the value: %s", err.Error())
+	}
+
+	expectChange(t, changes, "/port", Modified)
+
+	if err := provider.Delete("host"); err != nil {
+		t.Fatalf("can't delete the value: %s", err.Error())
+	}
+
+	expectChange(t, changes, "/host", Removed)
+}
+
+func TestFileConfProviderDeleteInMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"host": "db.local", "servers": [{"host": "alpha"}, {"host": "beta"}]}`)
+
+	provider, err := NewFileConfProvider(NewFileConfSource(path))
+	if err != nil {
+		t.Fatalf("can't create the provider: %s", err.Error())
+	}
+	defer provider.Dispose()
+
+	if err := provider.Delete("servers/$0"); err != nil {
+		t.Fatalf("can't delete the element of the array: %s", err.Error())
+	}
+
+	if err := provider.Delete("host"); err != nil {
+		t.Fatalf("can't delete the value: %s", err.Error())
+	}
+
+	if provider.ContainKey("host") || provider.Get("servers/$0/host") != "beta" || provider.ContainKey("servers/$1/host") {
+		t.Errorf("keys after the deletions, returned: %v", provider.Keys())
+	}
+
+	// without write-back the file is left untouched, so the reload brings the keys back
+	if err := provider.Reload(); err != nil {
+		t.Fatalf("can't reload the file: %s", err.Error())
+	}
+
+	if provider.Get("host") != "db.local" || provider.Get("servers/$0/host") != "alpha" {
+		t.Errorf("keys after the reload, returned: %v", provider.Keys())
+	}
+}
@@ -3,18 +3,24 @@ package gconf
 import (
 	"errors"
 	"fmt"
-	"runtime"
+	"path/filepath"
 	"sync"
 
 	"gopkg.in/fsnotify/fsnotify.v1"
 )
 
+// fileWatcher watches the directory of the file instead of the file itself,
+// so it keeps working when the file is replaced by a rename, deleted and recreated,
+// or is a symlink which is swapped to another target like the ConfigMap mounts of Kubernetes.
 type fileWatcher struct {
 	filePath    string
+	realPath    string
 	watcher     *fsnotify.Watcher
+	watchedDirs map[string]bool
 	changeToken ChangeToken
 	isWatching  bool
 	done        chan struct{}
+	mutex       sync.Mutex
 }
 
 func NewFileWatcher(filePath string) (Watcher, error) {
@@ -22,13 +28,18 @@ func NewFileWatcher(filePath string) (Watcher, error) {
 		return nil, errors.New("invalid filePath for file watcher")
 	}
 
-	fileInfo := NewFileInfo(filePath)
-	if !fileInfo.Exists() {
-		return nil, errors.New(fmt.Sprintf("the file[%s] doesn't exist", fileInfo.GetPhysicalPath()))
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("can't get the absolute path of the file[%s]: %s", filePath, err.Error()))
+	}
+
+	dirInfo := NewFileInfo(filepath.Dir(absPath))
+	if !dirInfo.Exists() || !dirInfo.IsDirectory() {
+		return nil, errors.New(fmt.Sprintf("the directory[%s] of the file doesn't exist", dirInfo.GetPhysicalPath()))
 	}
 
 	fileWatcher := &fileWatcher{
-		filePath:    filePath,
+		filePath:    absPath,
 		watcher:     nil,
 		changeToken: nil,
 		isWatching:  false,
@@ -47,101 +58,131 @@ func (f *fileWatcher) Watch(reloadToken ChangeToken) error {
 		return errors.New("can't create file watcher: " + err.Error())
 	}
 
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
 	f.watcher = watcher
+	f.watchedDirs = make(map[string]bool)
 	f.changeToken = reloadToken
-	f.watcher.Add(f.filePath)
 	f.done = make(chan struct{})
+	f.isWatching = true
 
-	if runtime.GOOS != "windows" {
-		go f.fileWatching()
-	} else {
-		go f.fileWatchingForWindows()
+	if err := f.addDir(filepath.Dir(f.filePath)); err != nil {
+		f.watcher.Close()
+		f.watcher = nil
+		f.isWatching = false
+		return errors.New(fmt.Sprintf("can't watch the directory of the file[%s]: %s", f.filePath, err.Error()))
 	}
 
+	f.realPath = f.resolve()
+
+	// the goroutine gets its own token, Watch may replace the field while it is running
+	go f.fileWatching(f.watcher, reloadToken, f.done)
+
 	return nil
 }
 
-func (f *fileWatcher) fileWatching() {
-	f.isWatching = true
+// addDir must be called with the mutex held.
+func (f *fileWatcher) addDir(dir string) error {
+	if f.watchedDirs[dir] {
+		return nil
+	}
 
-	defer f.Close()
+	if err := f.watcher.Add(dir); err != nil {
+		return err
+	}
 
+	f.watchedDirs[dir] = true
+	return nil
+}
+
+// resolve returns the path of the file with its symlinks evaluated, and watches the directory
+// of the target as well so the writes into the target are noticed. It must be called with the mutex held.
+func (f *fileWatcher) resolve() string {
+	realPath, err := filepath.EvalSymlinks(f.filePath)
+	if err != nil {
+		return ""
+	}
+
+	if dir := filepath.Dir(realPath); !f.watchedDirs[dir] {
+		f.addDir(dir)
+	}
+
+	return realPath
+}
+
+func (f *fileWatcher) fileWatching(watcher *fsnotify.Watcher, changeToken ChangeToken, done chan struct{}) {
 	for {
 		select {
-		case <-f.done:
+		case <-done:
 			return
 
-		case <-f.watcher.Errors:
-			break
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
 
-		case event := <-f.watcher.Events:
-			if len(event.Name) == 0 {
-				break
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
 			}
 
-			if event.Op^fsnotify.Chmod == 0 {
+			if !f.isChanged(event) {
 				break
 			}
 
-			f.changeToken.OnChanged()
+			changeToken.OnChanged()
 		}
 	}
 }
 
-func (f *fileWatcher) fileWatchingForWindows() {
-	isFired := false
-	f.isWatching = true
-	var mutex = &sync.Mutex{}
+// isChanged tells whether the event changes the file, which is either an event of the file
+// or of its symlink target, or an event which makes the file resolve to another target.
+func (f *fileWatcher) isChanged(event fsnotify.Event) bool {
+	if len(event.Name) == 0 || event.Op == fsnotify.Chmod {
+		return false
+	}
 
-	defer f.Close()
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 
-	for f.isWatching {
-		select {
-		case <-f.done:
-			return
+	if !f.isWatching {
+		return false
+	}
 
-		case <-f.watcher.Errors:
-			break
+	prevPath := f.realPath
+	f.realPath = f.resolve()
 
-		case event := <-f.watcher.Events:
-			if len(event.Name) == 0 {
-				break
-			}
+	name := filepath.Clean(event.Name)
 
-			if event.Op^fsnotify.Chmod == 0 {
-				break
-			}
-
-			mutex.Lock()
-			if !isFired {
-				f.changeToken.OnChanged()
-				isFired = true
-			} else {
-				isFired = false
-			}
-			mutex.Unlock()
-		}
-	}
+	return name == f.filePath || (prevPath != "" && name == prevPath) || prevPath != f.realPath
 }
 
 func (f *fileWatcher) IsWatching() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
 	return f.isWatching
 }
 
 func (f *fileWatcher) Close() error {
-	f.isWatching = false
-	f.done <- struct{}{}
-
-	if _, ok := <-f.done; ok {
-		close(f.done)
-	}
+	f.mutex.Lock()
 
-	if f.watcher == nil {
+	if !f.isWatching {
+		f.mutex.Unlock()
 		return nil
 	}
 
-	err := f.watcher.Close()
+	f.isWatching = false
+	close(f.done)
+
+	watcher := f.watcher
 	f.watcher = nil
+	f.mutex.Unlock()
+
+	if watcher == nil {
+		return nil
+	}
 
-	return err
+	return watcher.Close()
 }
@@ -0,0 +1,152 @@
+package gconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func waitForValue(t *testing.T, root ConfRoot, key string, expected string) {
+	deadline := time.Now().Add(3 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if root.TryGetString(key, "") == expected {
+			return
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.Errorf("value of %s, expected: %s, returned: %s", key, expected, root.TryGetString(key, ""))
+}
+
+func watchFile(t *testing.T, path string) ConfRoot {
+	root, err := NewConfBuilder().
+		Add(NewFileConfSource(path).SetEndureIfNotExist(true)).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	root.OnChange("", func(ConfChanges) {})
+
+	return root
+}
+
+func TestFileWatcherRenameOver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"version": "1"}`)
+
+	root := watchFile(t, path)
+	defer root.Dispose()
+
+	for _, version := range []string{"2", "3", "4"} {
+		writeFileAtomic(t, path, `{"version": "`+version+`"}`)
+		waitForValue(t, root, "version", version)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("can't remove the file: %s", err.Error())
+	}
+	waitForValue(t, root, "version", "")
+
+	writeFileAtomic(t, path, `{"version": "5"}`)
+	waitForValue(t, root, "version", "5")
+}
+
+func TestFileWatcherSymlinkSwap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the symlinks need privileges on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	// the layout of the ConfigMap volumes: app.json -> ..data/app.json, ..data -> ..v1
+	writeVersion := func(version string) {
+		versionDir := filepath.Join(dir, "..v"+version)
+
+		if err := os.Mkdir(versionDir, 0755); err != nil {
+			t.Fatalf("can't create the directory: %s", err.Error())
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(versionDir, "app.json"), []byte(`{"version": "`+version+`"}`), 0644); err != nil {
+			t.Fatalf("can't write the file: %s", err.Error())
+		}
+
+		tmpLink := filepath.Join(dir, "..data_tmp")
+		if err := os.Symlink("..v"+version, tmpLink); err != nil {
+			t.Fatalf("can't create the symlink: %s", err.Error())
+		}
+
+		if err := os.Rename(tmpLink, filepath.Join(dir, "..data")); err != nil {
+			t.Fatalf("can't swap the symlink: %s", err.Error())
+		}
+	}
+
+	writeVersion("1")
+
+	path := filepath.Join(dir, "app.json")
+	if err := os.Symlink(filepath.Join("..data", "app.json"), path); err != nil {
+		t.Fatalf("can't create the symlink: %s", err.Error())
+	}
+
+	root := watchFile(t, path)
+	defer root.Dispose()
+
+	for _, version := range []string{"2", "3"} {
+		writeVersion(version)
+		waitForValue(t, root, "version", version)
+	}
+}
+
+func TestFileWatcherRewatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"version": "1"}`)
+
+	watcher, err := NewFileWatcher(path)
+	if err != nil {
+		t.Fatalf("can't create the file watcher: %s", err.Error())
+	}
+	defer watcher.Close()
+
+	var token ChangeToken
+
+	// the tokens are replaced while the previous goroutines still deliver the events
+	for i := 0; i < 10; i++ {
+		token = NewChangeToken()
+		if err := watcher.Watch(token); err != nil {
+			t.Fatalf("can't watch the file: %s", err.Error())
+		}
+
+		writeFileAtomic(t, path, `{"version": "2"}`)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !token.HasChanged() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !token.HasChanged() {
+		t.Errorf("the token of the last Watch didn't change")
+	}
+}
@@ -0,0 +1,206 @@
+package gconf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	interpolationPrefix    = "${"
+	interpolationSuffix    = "}"
+	interpolationEscape    = "$${"
+	interpolationDefault   = ":-"
+	interpolationEnvPrefix = "env:"
+)
+
+// valueResolver is implemented by the configurations which resolve
+// the ${key} references of their values when they are read.
+type valueResolver interface {
+	getResolved(key string) (interface{}, error)
+}
+
+func resolveValue(confBase ConfBase, key string) (interface{}, error) {
+	if r, ok := confBase.(valueResolver); ok {
+		return r.getResolved(key)
+	}
+
+	value := confBase.Get(key)
+
+	if value == nil {
+		return nil, errorCantFindKey
+	}
+
+	return value, nil
+}
+
+func mapLookup(data map[string]interface{}) func(key string) (interface{}, bool) {
+	return func(key string) (interface{}, bool) {
+		value, exist := data[key]
+		return value, exist
+	}
+}
+
+// interpolator expands the references in the values:
+//
+//	${database/host}    the value of the key, resolved recursively
+//	${env:HOME}         the environment variable
+//	${KEY:-fallback}    the fallback when the key doesn't exist or is empty
+//	$${literal}         the literal "${literal}"
+type interpolator struct {
+	lookup func(key string) (interface{}, bool)
+}
+
+func newInterpolator(lookup func(key string) (interface{}, bool)) *interpolator {
+	return &interpolator{
+		lookup: lookup,
+	}
+}
+
+func (i *interpolator) get(key string) (interface{}, error) {
+	key = PathCombine(RootPath, key)
+
+	value, exist := i.lookup(key)
+	if !exist || value == nil {
+		return nil, errorCantFindKey
+	}
+
+	return i.resolve(value, []string{key})
+}
+
+func (i *interpolator) resolve(value interface{}, stack []string) (interface{}, error) {
+	s, ok := value.(string)
+
+	if !ok || !strings.Contains(s, "$") {
+		return value, nil
+	}
+
+	return i.expand(s, stack)
+}
+
+func (i *interpolator) expand(s string, stack []string) (interface{}, error) {
+	var buf strings.Builder
+
+	for len(s) > 0 {
+		if strings.HasPrefix(s, interpolationEscape) {
+			buf.WriteString(interpolationPrefix)
+			s = s[len(interpolationEscape):]
+			continue
+		}
+
+		if !strings.HasPrefix(s, interpolationPrefix) {
+			idx := strings.Index(s[1:], "$")
+			if idx == -1 {
+				buf.WriteString(s)
+				break
+			}
+
+			buf.WriteString(s[:idx+1])
+			s = s[idx+1:]
+			continue
+		}
+
+		end := findInterpolationEnd(s)
+		if end == -1 {
+			return nil, errors.New(fmt.Sprintf("[interpolator] unterminated reference in %q", s))
+		}
+
+		value, err := i.expandReference(s[len(interpolationPrefix):end], stack)
+		if err != nil {
+			return nil, err
+		}
+
+		// a value which is a single reference keeps the type of the referenced value
+		if buf.Len() == 0 && end+len(interpolationSuffix) == len(s) {
+			return value, nil
+		}
+
+		str, err := scalarToString(value)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("[interpolator] can't embed the value of ${%s} into a string: %s", s[len(interpolationPrefix):end], err.Error()))
+		}
+
+		buf.WriteString(str)
+		s = s[end+len(interpolationSuffix):]
+	}
+
+	return buf.String(), nil
+}
+
+// findInterpolationEnd returns the index of the suffix which closes the reference
+// at the start of s, the nested references of the fallback are skipped.
+func findInterpolationEnd(s string) int {
+	depth := 0
+
+	for idx := 0; idx < len(s); idx++ {
+		if strings.HasPrefix(s[idx:], interpolationPrefix) {
+			depth++
+			idx++
+			continue
+		}
+
+		if strings.HasPrefix(s[idx:], interpolationSuffix) {
+			depth--
+			if depth == 0 {
+				return idx
+			}
+		}
+	}
+
+	return -1
+}
+
+func (i *interpolator) expandReference(expr string, stack []string) (interface{}, error) {
+	ref := expr
+	fallback := ""
+	hasFallback := false
+
+	if idx := strings.Index(expr, interpolationDefault); idx != -1 {
+		ref = expr[:idx]
+		fallback = expr[idx+len(interpolationDefault):]
+		hasFallback = true
+	}
+
+	value, exist, err := i.lookupReference(strings.TrimSpace(ref), stack)
+	if err != nil {
+		return nil, err
+	}
+
+	if exist && (!hasFallback || value != "") {
+		return value, nil
+	}
+
+	if hasFallback {
+		return i.expand(fallback, stack)
+	}
+
+	return nil, errors.New(fmt.Sprintf("[interpolator] can't resolve the reference ${%s} of the key[%s]", expr, stack[len(stack)-1]))
+}
+
+func (i *interpolator) lookupReference(ref string, stack []string) (interface{}, bool, error) {
+	if strings.HasPrefix(ref, interpolationEnvPrefix) {
+		value, exist := os.LookupEnv(ref[len(interpolationEnvPrefix):])
+		return value, exist, nil
+	}
+
+	key := PathCombine(RootPath, ref)
+
+	for _, k := range stack {
+		if strings.EqualFold(k, key) {
+			return nil, false, errors.New(fmt.Sprintf("[interpolator] reference cycle detected: %s -> %s", strings.Join(stack, " -> "), key))
+		}
+	}
+
+	value, exist := i.lookup(key)
+	if !exist || value == nil {
+		return nil, false, nil
+	}
+
+	value, err := i.resolve(value, append(stack[:len(stack):len(stack)], key))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
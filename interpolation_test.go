@@ -0,0 +1,87 @@
+package gconf
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInterpolation(t *testing.T) {
+	os.Setenv("GCONF_TEST_HOME", "/home/gconf")
+	defer os.Unsetenv("GCONF_TEST_HOME")
+
+	base := []byte(`{
+		"database": { "host": "db.local", "port": 5432 },
+		"cycle": { "a": "${cycle/b}", "b": "${cycle/a}" }
+	}`)
+
+	prod := []byte(`{
+		"url": "${database/host}:${database/port}",
+		"port": "${database/port}",
+		"data": "${env:GCONF_TEST_HOME}/data",
+		"fallback": "${missing/key:-${database/host}}",
+		"escaped": "$${literal} costs $5",
+		"broken": "${missing/key}"
+	}`)
+
+	conf, err := NewConfBuilder().
+		Add(NewJsonConfSource(base)).
+		Add(NewJsonConfSource(prod)).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	expectations := map[string]string{
+		"url":      "db.local:5432",
+		"data":     "/home/gconf/data",
+		"fallback": "db.local",
+		"escaped":  "${literal} costs $5",
+	}
+
+	for key, expected := range expectations {
+		returned, err := conf.GetString(key)
+
+		if err != nil {
+			t.Errorf("interpolation of %s returned an error: %s", key, err.Error())
+			continue
+		}
+
+		if returned != expected {
+			t.Errorf("interpolation of %s, expected: %s, returned: %s", key, expected, returned)
+		}
+	}
+
+	if port, err := conf.GetInt("port"); err != nil || port != 5432 {
+		t.Errorf("interpolation of a single reference, expected: %d, returned: %d, %v", 5432, port, err)
+	}
+
+	if host, err := conf.GetSection("database").GetString("host"); err != nil || host != "db.local" {
+		t.Errorf("section lookup, expected: %s, returned: %s, %v", "db.local", host, err)
+	}
+
+	if _, err := conf.GetString("cycle/a"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("interpolation of a reference cycle, expected: a cycle error, returned: %v", err)
+	}
+
+	if _, err := conf.GetString("broken"); err == nil {
+		t.Errorf("interpolation of a missing reference, expected: an error, returned: nil")
+	}
+
+	if returned := conf.Get("broken"); returned != nil {
+		t.Errorf("Get of an unresolvable value, expected: nil, returned: %v", returned)
+	}
+
+	if _, err := conf.GetValue("cycle/b"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("value of a reference cycle, expected: a cycle error, returned: %v", err)
+	}
+
+	if _, err := conf.GetSection("cycle").GetValue("a"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("value of a reference cycle in a section, expected: a cycle error, returned: %v", err)
+	}
+
+	if value, err := conf.GetValue("port"); err != nil || value != float64(5432) {
+		t.Errorf("value of a single reference, expected: %d, returned: %v, %v", 5432, value, err)
+	}
+}
@@ -62,19 +62,36 @@ func (p *jsonConfParser) Parse(stream []byte) error {
 	return nil
 }
 
+// ParseValue flattens the value which can be a scalar or nested maps and slices of any type,
+// the same way as the decoded json.
+func (p *jsonConfParser) ParseValue(value interface{}) {
+	p.parse(value, p.rootPath)
+}
+
 func (p *jsonConfParser) parse(value interface{}, path string) {
+	// the nulls are kept, they remove the key from the merged view of the root
 	if value == nil {
+		if path != p.rootPath {
+			p.dataMap[path] = nil
+		}
 		return
 	}
 
 	rv := reflect.ValueOf(value)
 
 	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			p.parse(nil, path)
+			return
+		}
+		p.parse(rv.Elem().Interface(), path)
+		break
 	case reflect.Map:
-		p.parseMap(value, path)
+		p.parseMap(rv, path)
 		break
 	case reflect.Slice, reflect.Array:
-		p.parseArray(value, path)
+		p.parseArray(rv, path)
 		break
 	default:
 		p.dataMap[path] = value
@@ -82,38 +99,17 @@ func (p *jsonConfParser) parse(value interface{}, path string) {
 	}
 }
 
-func (p *jsonConfParser) parseArray(raw interface{}, parentKey string) {
-	if raw == nil {
-		return
-	}
-
-	data, ok := raw.([]interface{})
-
-	if !ok {
-		return
-	}
-
-	for idx, v := range data {
-		newPath := PathCombine(parentKey, ArrayDelimiter+ fmt.Sprint(idx))
-		p.parse(v, newPath)
+func (p *jsonConfParser) parseArray(rv reflect.Value, parentKey string) {
+	for idx := 0; idx < rv.Len(); idx++ {
+		newPath := PathCombine(parentKey, ArrayDelimiter+fmt.Sprint(idx))
+		p.parse(rv.Index(idx).Interface(), newPath)
 	}
 }
 
-func (p *jsonConfParser) parseMap(raw interface{}, parentKey string) {
-
-	if raw == nil {
-		return
-	}
-
-	data, ok := raw.(map[string]interface{})
-
-	if !ok {
-		return
-	}
-
-	for k, v := range data {
-		newPath := PathCombine(parentKey, k)
-		p.parse(v, newPath)
+func (p *jsonConfParser) parseMap(rv reflect.Value, parentKey string) {
+	for _, k := range rv.MapKeys() {
+		newPath := PathCombine(parentKey, fmt.Sprint(k.Interface()))
+		p.parse(rv.MapIndex(k).Interface(), newPath)
 	}
 }
 
@@ -0,0 +1,404 @@
+package gconf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type KeyPosition struct {
+	Line   int
+	Column int
+}
+
+// KeyLocator is implemented by the configuration sources which can tell
+// where a key is declared in the underlying file.
+type KeyLocator interface {
+	LocateKey(key string) (KeyPosition, error)
+}
+
+// loadCommitter is implemented by the sources which keep what they learned about the contents
+// they loaded, such as the positions of the keys, until the provider commits the contents.
+type loadCommitter interface {
+	commitLoad()
+}
+
+// KeyPositionScanner is implemented by the parsers which can find the positions
+// of the keys in the contents they parse.
+type KeyPositionScanner interface {
+	ScanKeyPositions(stream []byte) (map[string]KeyPosition, error)
+}
+
+// locateKey finds the position of the key, falling back to the nearest declared parent
+// for the values which have no position of their own such as the elements of an inline array.
+func locateKey(positions map[string]KeyPosition, key string) (KeyPosition, bool) {
+	key = PathCombine(RootPath, key)
+
+	for {
+		if pos, ok := positions[key]; ok {
+			return pos, true
+		}
+
+		if key == RootPath {
+			return KeyPosition{}, false
+		}
+
+		key = PathCombine(getDirectParentPath(key))
+	}
+}
+
+// getDirectParentPath returns the path of the direct parent of the key.
+// Unlike GetParentPath it doesn't skip the array indexes.
+func getDirectParentPath(key string) string {
+	idx := strings.LastIndex(key, PathDelimiter)
+
+	if idx <= 0 {
+		return RootPath
+	}
+
+	return key[:idx]
+}
+
+type jsonPositionScanner struct {
+	data      []byte
+	offset    int
+	line      int
+	column    int
+	positions map[string]KeyPosition
+}
+
+func scanJsonPositions(stream []byte) (map[string]KeyPosition, error) {
+	s := &jsonPositionScanner{
+		data:      stream,
+		line:      1,
+		column:    1,
+		positions: make(map[string]KeyPosition),
+	}
+
+	if err := s.scanValue(RootPath); err != nil {
+		return nil, err
+	}
+
+	return s.positions, nil
+}
+
+func (s *jsonPositionScanner) position() KeyPosition {
+	return KeyPosition{Line: s.line, Column: s.column}
+}
+
+func (s *jsonPositionScanner) advance() {
+	if s.data[s.offset] == '\n' {
+		s.line++
+		s.column = 1
+	} else {
+		s.column++
+	}
+
+	s.offset++
+}
+
+func (s *jsonPositionScanner) skipWhitespaces() {
+	for s.offset < len(s.data) {
+		switch s.data[s.offset] {
+		case ' ', '\t', '\r', '\n':
+			s.advance()
+		default:
+			return
+		}
+	}
+}
+
+func (s *jsonPositionScanner) peek() (byte, error) {
+	s.skipWhitespaces()
+
+	if s.offset >= len(s.data) {
+		return 0, errors.New("[jsonPositionScanner] unexpected end of json")
+	}
+
+	return s.data[s.offset], nil
+}
+
+func (s *jsonPositionScanner) expect(c byte) error {
+	next, err := s.peek()
+	if err != nil {
+		return err
+	}
+
+	if next != c {
+		return errors.New(fmt.Sprintf("[jsonPositionScanner] expected '%c' but found '%c' at %d:%d", c, next, s.line, s.column))
+	}
+
+	s.advance()
+	return nil
+}
+
+func (s *jsonPositionScanner) scanValue(path string) error {
+	c, err := s.peek()
+	if err != nil {
+		return err
+	}
+
+	switch c {
+	case '{':
+		return s.scanObject(path)
+	case '[':
+		return s.scanArray(path)
+	case '"':
+		_, err := s.scanString()
+		return err
+	}
+
+	for s.offset < len(s.data) {
+		switch s.data[s.offset] {
+		case ',', '}', ']', ' ', '\t', '\r', '\n':
+			return nil
+		}
+		s.advance()
+	}
+
+	return nil
+}
+
+func (s *jsonPositionScanner) scanObject(path string) error {
+	s.advance()
+
+	for {
+		c, err := s.peek()
+		if err != nil {
+			return err
+		}
+
+		if c == '}' {
+			s.advance()
+			return nil
+		}
+
+		pos := s.position()
+		name, err := s.scanString()
+		if err != nil {
+			return err
+		}
+
+		key := PathCombine(path, name)
+		s.positions[key] = pos
+
+		if err := s.expect(':'); err != nil {
+			return err
+		}
+
+		if err := s.scanValue(key); err != nil {
+			return err
+		}
+
+		if c, err = s.peek(); err != nil {
+			return err
+		}
+
+		if c == ',' {
+			s.advance()
+		}
+	}
+}
+
+func (s *jsonPositionScanner) scanArray(path string) error {
+	s.advance()
+
+	for idx := 0; ; idx++ {
+		c, err := s.peek()
+		if err != nil {
+			return err
+		}
+
+		if c == ']' {
+			s.advance()
+			return nil
+		}
+
+		key := GetArrayIndexPath(path, idx)
+		s.positions[key] = s.position()
+
+		if err := s.scanValue(key); err != nil {
+			return err
+		}
+
+		if c, err = s.peek(); err != nil {
+			return err
+		}
+
+		if c == ',' {
+			s.advance()
+		}
+	}
+}
+
+func (s *jsonPositionScanner) scanString() (string, error) {
+	if err := s.expect('"'); err != nil {
+		return "", err
+	}
+
+	start := s.offset - 1
+
+	for s.offset < len(s.data) {
+		c := s.data[s.offset]
+		s.advance()
+
+		if c == '\\' && s.offset < len(s.data) {
+			s.advance()
+			continue
+		}
+
+		if c == '"' {
+			var str string
+			err := json.Unmarshal(s.data[start:s.offset], &str)
+			return str, err
+		}
+	}
+
+	return "", errors.New("[jsonPositionScanner] unexpected end of string")
+}
+
+func scanYamlPositions(stream []byte) (map[string]KeyPosition, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(stream, &doc); err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]KeyPosition)
+
+	for _, n := range doc.Content {
+		scanYamlNode(n, RootPath, positions)
+	}
+
+	return positions, nil
+}
+
+func scanYamlNode(node *yaml.Node, path string, positions map[string]KeyPosition) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		scanYamlNode(node.Alias, path, positions)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			v := node.Content[i+1]
+
+			if k.Value == "<<" && k.Tag == "!!merge" {
+				scanYamlMerge(v, path, positions)
+				continue
+			}
+
+			key := PathCombine(path, k.Value)
+			positions[key] = KeyPosition{Line: k.Line, Column: k.Column}
+			scanYamlNode(v, key, positions)
+		}
+	case yaml.SequenceNode:
+		for idx, item := range node.Content {
+			key := GetArrayIndexPath(path, idx)
+			positions[key] = KeyPosition{Line: item.Line, Column: item.Column}
+			scanYamlNode(item, key, positions)
+		}
+	}
+}
+
+// scanYamlMerge adds the positions of the merged keys which aren't declared explicitly.
+func scanYamlMerge(node *yaml.Node, path string, positions map[string]KeyPosition) {
+	merged := make(map[string]KeyPosition)
+
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			scanYamlNode(item, path, merged)
+		}
+	} else {
+		scanYamlNode(node, path, merged)
+	}
+
+	for k, v := range merged {
+		if _, exist := positions[k]; !exist {
+			positions[k] = v
+		}
+	}
+}
+
+func scanTomlPositions(stream []byte) (map[string]KeyPosition, error) {
+	positions := make(map[string]KeyPosition)
+	tableIndexes := make(map[string]int)
+	table := RootPath
+	inMultiLineString := false
+
+	for lineNo, line := range strings.Split(string(stream), "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if strings.Count(line, `"""`)%2 == 1 || strings.Count(line, `'''`)%2 == 1 {
+			inMultiLineString = !inMultiLineString
+			if !inMultiLineString {
+				continue
+			}
+		} else if inMultiLineString {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		column := strings.Index(line, trimmed) + 1
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[[") {
+			end := strings.Index(trimmed, "]]")
+			if end == -1 {
+				continue
+			}
+
+			arrayPath := PathCombine(append([]string{RootPath}, splitTomlKey(trimmed[2:end])...)...)
+			idx := tableIndexes[arrayPath]
+			tableIndexes[arrayPath] = idx + 1
+
+			table = GetArrayIndexPath(arrayPath, idx)
+			positions[table] = KeyPosition{Line: lineNo + 1, Column: column}
+			if _, exist := positions[arrayPath]; !exist {
+				positions[arrayPath] = positions[table]
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			end := strings.Index(trimmed, "]")
+			if end == -1 {
+				continue
+			}
+
+			table = PathCombine(append([]string{RootPath}, splitTomlKey(trimmed[1:end])...)...)
+			positions[table] = KeyPosition{Line: lineNo + 1, Column: column}
+			continue
+		}
+
+		eq := strings.Index(trimmed, "=")
+		if eq == -1 {
+			continue
+		}
+
+		key := PathCombine(append([]string{table}, splitTomlKey(trimmed[:eq])...)...)
+		positions[key] = KeyPosition{Line: lineNo + 1, Column: column}
+	}
+
+	return positions, nil
+}
+
+func splitTomlKey(key string) []string {
+	var entities []string
+
+	for _, e := range strings.Split(key, ".") {
+		e = strings.TrimSpace(e)
+		e = strings.Trim(e, `"'`)
+
+		if e != "" {
+			entities = append(entities, e)
+		}
+	}
+
+	return entities
+}
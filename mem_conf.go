@@ -1,18 +1,165 @@
 package gconf
 
+import (
+	"reflect"
+	"sync"
+)
+
 type MemConfSource struct {
+	data                  map[string]interface{}
+	providers             []*confProvider
+	onConfChangedCallback func(ConfChanges)
+	validator             ConfValidator
+	mutex                 sync.Mutex
 }
 
+// NewMapConfSource creates the source of the map which can hold nested maps and slices,
+// they are flattened into the paths the same way as the json configuration.
 func NewMapConfSource(conf map[string]interface{}) *MemConfSource {
-	return &MemConfSource{}
+	return &MemConfSource{
+		data: flattenValue(RootPath, conf),
+	}
+}
+
+func flattenValue(path string, value interface{}) map[string]interface{} {
+	parser := newJsonConfParser(path, PathDelimiter)
+	parser.ParseValue(value)
+
+	data := parser.GetDataMap()
+
+	// the parser skips a null at its root, the null set at a key is kept as the tombstone of the key
+	if len(data) == 0 && path != RootPath && isNullValue(value) {
+		data[path] = nil
+	}
+
+	return data
+}
+
+func isNullValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(value)
+
+	return (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil()
 }
 
 func (s *MemConfSource) Build(builder ConfBuilder) (ConfProvider, error) {
-	return NewConfProvider(s)
+	p, err := NewConfProvider(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if cp, ok := p.(*confProvider); ok {
+		s.mutex.Lock()
+		s.providers = append(s.providers, cp)
+		s.mutex.Unlock()
+	}
+
+	return p, nil
 }
 
 func (s *MemConfSource) Load() (map[string]interface{}, error) {
-	data := make(map[string]interface{})
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return copyMap(s.data), nil
+}
+
+// Set replaces the key and everything under it with the value, which can be a nested map or slice.
+// A nil value is kept as a tombstone, which removes the key from the merged view of the root.
+// The providers built from the source are notified of the changes, and nothing is changed
+// when the change is rejected by a validator.
+func (s *MemConfSource) Set(key string, value interface{}) error {
+	return s.setValue(key, value)
+}
+
+func (s *MemConfSource) setValue(key string, value interface{}) error {
+	key = PathCombine(RootPath, key)
+	values := flattenValue(key, value)
+
+	return s.update(func(data map[string]interface{}) {
+		for k := range data {
+			if IsKeyInPath(key, k) {
+				delete(data, k)
+			}
+		}
+
+		for k, v := range values {
+			data[k] = v
+		}
+	})
+}
+
+// Delete removes the key and everything under it, an element of an array is removed
+// with the following elements shifted down. The providers built from the source are notified
+// of the changes, and nothing is changed when the change is rejected by a validator.
+func (s *MemConfSource) Delete(key string) error {
+	_, err := s.removeValue(key, true)
+	return err
+}
+
+func (s *MemConfSource) removeValue(key string, section bool) (bool, error) {
+	removed := false
+
+	err := s.update(func(data map[string]interface{}) {
+		removed = removeKeys(data, key, section)
+	})
+
+	return removed, err
+}
+
+// update applies the change to a copy of the data, which replaces the data only when every
+// provider accepts it. The providers commit the data under the lock, so the concurrent updates
+// are committed in the order they are applied, and they are notified after it.
+func (s *MemConfSource) update(change func(data map[string]interface{})) error {
+	s.mutex.Lock()
+
+	data := copyMap(s.data)
+	change(data)
+
+	for _, p := range s.providers {
+		if err := p.validate(data); err != nil {
+			s.mutex.Unlock()
+			p.fireReloadFailed(err)
+			return err
+		}
+	}
+
+	s.data = data
+
+	providers := s.providers
+	changes := make([]ConfChanges, len(providers))
+
+	for i, p := range providers {
+		changes[i] = p.store.commit(data)
+	}
+
+	s.mutex.Unlock()
+
+	for i, p := range providers {
+		p.notify(changes[i])
+	}
+
+	return nil
+}
+
+// SetValidator sets the validator of the contents of the source, the changes it rejects aren't applied.
+func (s *MemConfSource) SetValidator(validator ConfValidator) *MemConfSource {
+	s.validator = validator
+	return s
+}
+
+func (s *MemConfSource) GetValidator() ConfValidator {
+	return s.validator
+}
+
+func (s *MemConfSource) SetOnConfChangedCallback(onConfChangedCallback func(ConfChanges)) *MemConfSource {
+	s.onConfChangedCallback = onConfChangedCallback
+	return s
+}
 
-	return data, nil
+func (s *MemConfSource) GetOnConfChangedCallback() func(ConfChanges) {
+	return s.onConfChangedCallback
 }
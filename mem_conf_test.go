@@ -0,0 +1,190 @@
+package gconf
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func expectChange(t *testing.T, changes chan ConfChanges, key string, mode ChangeMode) {
+	select {
+	case c := <-changes:
+		for _, change := range c.GetChanges() {
+			if change.KeyName == key && change.Mode == mode {
+				return
+			}
+		}
+
+		t.Errorf("changes of the key[%s], expected: mode %d, returned: %v", key, mode, c.GetChanges())
+	case <-time.After(time.Second):
+		t.Errorf("the change of the key[%s] wasn't notified", key)
+	}
+}
+
+func TestMemConfSource(t *testing.T) {
+	source := NewMapConfSource(map[string]interface{}{
+		"logging": map[string]string{"level": "info"},
+		"servers": []interface{}{
+			map[string]interface{}{"host": "alpha"},
+			map[string]interface{}{"host": "beta"},
+		},
+		"ports": []int{80, 443},
+	})
+
+	root, err := NewConfBuilder().Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	expected := map[string]interface{}{
+		"/logging/level":   "info",
+		"/servers/$1/host": "beta",
+		"/ports/$1":        443,
+	}
+
+	for key, value := range expected {
+		if root.Get(key) != value {
+			t.Errorf("value of the key[%s], expected: %v, returned: %v", key, value, root.Get(key))
+		}
+	}
+
+	changes := make(chan ConfChanges, 10)
+	root.OnChange("", func(c ConfChanges) { changes <- c })
+
+	token := root.(*confRoot).providers[0].GetChangeToken()
+
+	if err := source.Set("logging/level", "debug"); err != nil {
+		t.Fatalf("can't set the key: %s", err.Error())
+	}
+
+	expectChange(t, changes, "/logging/level", Modified)
+
+	if !token.HasChanged() {
+		t.Errorf("the change token of the provider didn't fire on Set")
+	}
+
+	if err := source.Set("logging", map[string]interface{}{"file": "app.log"}); err != nil {
+		t.Fatalf("can't set the section: %s", err.Error())
+	}
+
+	expectChange(t, changes, "/logging/level", Removed)
+
+	if root.Get("logging/file") != "app.log" {
+		t.Errorf("value of the replaced section, expected: app.log, returned: %v", root.Get("logging/file"))
+	}
+
+	if err := source.Delete("servers/$0"); err != nil {
+		t.Fatalf("can't delete the key: %s", err.Error())
+	}
+
+	expectChange(t, changes, "/servers/$1/host", Removed)
+
+	if root.GetArraySection("servers").Length() != 1 || root.Get("servers/$0/host") != "beta" {
+		t.Errorf("array after the deletion, returned: %v", root.GetSection("servers").Keys())
+	}
+}
+
+func TestMemConfSourceConcurrentSet(t *testing.T) {
+	source := NewMapConfSource(nil)
+
+	root, err := NewConfBuilder().Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			source.Set(fmt.Sprintf("key%d", i), i)
+		}(i)
+	}
+
+	wg.Wait()
+
+	if keys := root.Keys(); len(keys) != 20 {
+		t.Errorf("keys after the concurrent sets, expected: 20, returned: %d", len(keys))
+	}
+}
+
+func TestMemConfSourceSetNull(t *testing.T) {
+	base := NewMapConfSource(map[string]interface{}{
+		"feature": map[string]interface{}{"enabled": true, "limit": 10},
+	})
+
+	source := NewMapConfSource(map[string]interface{}{
+		"feature": map[string]interface{}{"limit": 20},
+	})
+
+	root, err := NewConfBuilder().Add(base).Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	if err := source.Set("feature/limit", nil); err != nil {
+		t.Fatalf("can't set the null: %s", err.Error())
+	}
+
+	if root.ContainKey("feature/limit") || !root.ContainKey("feature/enabled") {
+		t.Errorf("keys after the null is set, expected: [/feature/enabled], returned: %v", root.Keys())
+	}
+
+	data, _ := source.Load()
+
+	if value, exist := data["/feature/limit"]; !exist || value != nil {
+		t.Errorf("tombstone of the key, expected: nil, returned: %v, %v", value, exist)
+	}
+
+	var options *testOptions
+
+	if err := source.Set("feature/enabled", options); err != nil {
+		t.Fatalf("can't set the null pointer: %s", err.Error())
+	}
+
+	if root.ContainKey("feature/enabled") {
+		t.Errorf("keys after the null pointer is set, expected: none, returned: %v", root.Keys())
+	}
+}
+
+func TestMemConfProviderSet(t *testing.T) {
+	source := NewMapConfSource(map[string]interface{}{"a": 1, "c": 1})
+
+	root, err := NewConfBuilder().Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	changes := make(chan ConfChanges, 10)
+	root.OnChange("", func(c ConfChanges) { changes <- c })
+
+	if err := root.Set("a", 2); err != nil {
+		t.Fatalf("can't set the key: %s", err.Error())
+	}
+
+	expectChange(t, changes, "/a", Modified)
+
+	if err := root.Delete("c"); err != nil {
+		t.Fatalf("can't delete the key: %s", err.Error())
+	}
+
+	expectChange(t, changes, "/c", Removed)
+
+	// the values set on the provider are kept in the source
+	if err := source.Set("b", 3); err != nil {
+		t.Fatalf("can't set the key: %s", err.Error())
+	}
+
+	expectChange(t, changes, "/b", Created)
+
+	if root.Get("a") != 2 || root.ContainKey("c") {
+		t.Errorf("keys after the set on the source, returned: %v", root.Keys())
+	}
+
+	if err := root.(*confRoot).providers[0].Delete("missing"); err == nil {
+		t.Errorf("Delete of a missing key, expected: an error, returned: nil")
+	}
+}
@@ -0,0 +1,214 @@
+package gconf
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+type OptionsMonitor[T any] interface {
+	GetPath() string
+	Current() T
+	OnChange(callback func(prev T, current T))
+	LastError() error
+	Close()
+}
+
+type optionsValue[T any] struct {
+	value T
+}
+
+type optionsMonitor[T any] struct {
+	path         string
+	root         ConfRoot
+	defaults     T
+	current      atomic.Value
+	lastError    error
+	registration ChangeTokenRegistration
+	closed       bool
+	mutex        sync.Mutex
+	callbacks    []func(T, T)
+}
+
+// Bind binds the section of the key into a new value of T and keeps it up to date whenever
+// the keys under the section are changed by a configuration provider. The defaults are
+// deep-copied for every bind, so the values Current() returned are never changed afterwards.
+// T is either a struct or a pointer to a struct.
+func Bind[T any](root ConfRoot, key string, defaults T) (OptionsMonitor[T], error) {
+	if root == nil {
+		return nil, errors.New("[OptionsMonitor::Bind] invalid null argument: root")
+	}
+
+	if v := reflect.ValueOf(&defaults).Elem(); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, errors.New("[OptionsMonitor::Bind] invalid null pointer argument: defaults")
+	}
+
+	m := &optionsMonitor[T]{
+		path:     PathCombine(root.GetPath(), key),
+		root:     root,
+		defaults: defaults,
+	}
+
+	value, err := m.bind()
+	if err != nil {
+		return nil, err
+	}
+
+	m.current.Store(&optionsValue[T]{value: value})
+
+	m.registration = root.OnChange(m.path, m.onConfChanged)
+
+	return m, nil
+}
+
+func (m *optionsMonitor[T]) GetPath() string {
+	return m.path
+}
+
+func (m *optionsMonitor[T]) Current() T {
+	return m.current.Load().(*optionsValue[T]).value
+}
+
+// OnChange registers the callback of the rebinds, it is called with the previous value
+// and the new one after the keys under the section changed.
+func (m *optionsMonitor[T]) OnChange(callback func(prev T, current T)) {
+	if callback == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return
+	}
+
+	m.callbacks = append(m.callbacks, callback)
+}
+
+// LastError returns the error of the last rebind, nil when it succeeded. Current() keeps
+// the last value which was bound successfully.
+func (m *optionsMonitor[T]) LastError() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.lastError
+}
+
+// Close stops the rebinds and drops the callbacks, Current() keeps returning the last value.
+func (m *optionsMonitor[T]) Close() {
+	m.mutex.Lock()
+	registration := m.registration
+	m.registration = nil
+	m.closed = true
+	m.callbacks = nil
+	m.mutex.Unlock()
+
+	if registration != nil {
+		registration.Unregister()
+	}
+}
+
+func (m *optionsMonitor[T]) bind() (T, error) {
+	v := reflect.New(reflect.TypeOf(&m.defaults).Elem())
+	v.Elem().Set(deepCopyValue(reflect.ValueOf(&m.defaults).Elem()))
+
+	if err := m.root.Unmarshal(m.path, v.Interface()); err != nil {
+		var empty T
+		return empty, errors.New(fmt.Sprintf("[OptionsMonitor::bind] can't bind the section[%s]: %s", m.path, err.Error()))
+	}
+
+	return *(v.Interface().(*T)), nil
+}
+
+func (m *optionsMonitor[T]) onConfChanged(changes ConfChanges) {
+	m.mutex.Lock()
+
+	// a notification which was already dispatched when the monitor was closed
+	if m.closed {
+		m.mutex.Unlock()
+		return
+	}
+
+	value, err := m.bind()
+	m.lastError = err
+
+	if err != nil {
+		m.mutex.Unlock()
+		log.Println(err.Error())
+		return
+	}
+
+	prev := m.current.Load().(*optionsValue[T]).value
+	m.current.Store(&optionsValue[T]{value: value})
+
+	callbacks := m.callbacks
+	m.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback(prev, value)
+	}
+}
+
+// deepCopyValue copies the maps, slices and pointers of the value, so nothing
+// the copy refers to is shared with the original.
+func deepCopyValue(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return c
+		}
+
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(deepCopyValue(v.Elem()))
+		c.Set(p)
+	case reflect.Interface:
+		if v.IsNil() {
+			return c
+		}
+
+		c.Set(deepCopyValue(v.Elem()))
+	case reflect.Map:
+		if v.IsNil() {
+			return c
+		}
+
+		c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+
+		for _, k := range v.MapKeys() {
+			c.SetMapIndex(k, deepCopyValue(v.MapIndex(k)))
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return c
+		}
+
+		c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+	case reflect.Struct:
+		// the unexported fields can't be set one by one, they are copied with the struct
+		c.Set(v)
+
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopyValue(v.Field(i)))
+			}
+		}
+	default:
+		c.Set(v)
+	}
+
+	return c
+}
@@ -0,0 +1,146 @@
+package gconf
+
+import (
+	"testing"
+	"time"
+)
+
+type testOptions struct {
+	Level   string
+	Retries int
+	Tags    map[string]string
+}
+
+type testOptionsChange struct {
+	prev    testOptions
+	current testOptions
+}
+
+func TestOptionsMonitor(t *testing.T) {
+	source := NewMapConfSource(map[string]interface{}{
+		"app": map[string]interface{}{
+			"level": "info",
+			"tags":  map[string]interface{}{"a": "1"},
+		},
+		"other": "x",
+	})
+
+	root, err := NewConfBuilder().Add(source).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	defaults := testOptions{Retries: 3, Tags: map[string]string{"def": "x"}}
+
+	monitor, err := Bind(root, "app", defaults)
+	if err != nil {
+		t.Fatalf("can't bind the section: %s", err.Error())
+	}
+
+	if len(defaults.Tags) != 1 {
+		t.Errorf("tags of the defaults after the bind, expected: map[def:x], returned: %v", defaults.Tags)
+	}
+
+	first := monitor.Current()
+
+	if first.Level != "info" || first.Retries != 3 || first.Tags["def"] != "x" || first.Tags["a"] != "1" {
+		t.Errorf("bound value, returned: %+v", first)
+	}
+
+	changes := make(chan testOptionsChange, 10)
+
+	monitor.OnChange(func(prev testOptions, current testOptions) {
+		// registering from a callback must not deadlock
+		monitor.OnChange(func(testOptions, testOptions) {})
+		changes <- testOptionsChange{prev, current}
+	})
+
+	if err := source.Set("other", "y"); err != nil {
+		t.Fatalf("can't set the key: %s", err.Error())
+	}
+
+	if err := source.Set("app/tags/b", "2"); err != nil {
+		t.Fatalf("can't set the key: %s", err.Error())
+	}
+
+	select {
+	case c := <-changes:
+		if len(c.prev.Tags) != 2 || c.prev.Tags["b"] != "" || c.current.Tags["b"] != "2" {
+			t.Errorf("values of the callback, returned: %+v, %+v", c.prev, c.current)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("the callback of the change isn't called")
+	}
+
+	if monitor.Current().Tags["b"] != "2" {
+		t.Errorf("current value after the change, returned: %+v", monitor.Current())
+	}
+
+	if _, exist := first.Tags["b"]; exist || len(defaults.Tags) != 1 {
+		t.Errorf("the rebind changed the values already returned: %v, %v", first.Tags, defaults.Tags)
+	}
+
+	if err := source.Set("app/retries", "many"); err != nil {
+		t.Fatalf("can't set the key: %s", err.Error())
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for monitor.LastError() == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if monitor.LastError() == nil {
+		t.Errorf("error of the invalid rebind, expected: an error, returned: nil")
+	}
+
+	if monitor.Current().Retries != 3 || len(changes) != 0 {
+		t.Errorf("the invalid rebind replaced the current value: %+v", monitor.Current())
+	}
+
+	if err := source.Set("app/retries", 5); err != nil {
+		t.Fatalf("can't set the key: %s", err.Error())
+	}
+
+	select {
+	case c := <-changes:
+		if c.prev.Retries != 3 || c.current.Retries != 5 || monitor.LastError() != nil {
+			t.Errorf("rebind after the error, returned: %+v, %v", c.current, monitor.LastError())
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("the callback of the change isn't called")
+	}
+
+	pointer, err := Bind(root, "app", &testOptions{Retries: 1})
+	if err != nil {
+		t.Fatalf("can't bind the section to a pointer: %s", err.Error())
+	}
+
+	if pointer.Current().Retries != 5 || pointer.Current().Level != "info" {
+		t.Errorf("bound pointer, returned: %+v", pointer.Current())
+	}
+
+	if _, err := Bind[*testOptions](root, "app", nil); err == nil {
+		t.Errorf("bind with the nil defaults, expected: an error, returned: nil")
+	}
+
+	monitor.Close()
+	pointer.Close()
+
+	if err := source.Set("app/retries", 7); err != nil {
+		t.Fatalf("can't set the key: %s", err.Error())
+	}
+
+	select {
+	case c := <-changes:
+		t.Errorf("callback of the closed monitor, returned: %+v", c.current)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if monitor.Current().Retries != 5 || pointer.Current().Retries != 5 {
+		t.Errorf("current value of the closed monitor, expected: 5, returned: %d", monitor.Current().Retries)
+	}
+
+	if subscribers := len(root.(*confRoot).subscribers); subscribers != 0 {
+		t.Errorf("subscribers of the root after the monitors are closed, expected: 0, returned: %d", subscribers)
+	}
+}
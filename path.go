@@ -70,14 +70,33 @@ func GetParentPath(path string) string {
 	return newPath
 }
 
+// HasPathInKey reports whether the key starts with the path, ignoring the case.
+// It compares plain strings, use IsKeyInPath to find the keys of a section.
 func HasPathInKey(path, key string) bool {
 	return strings.HasPrefix(strings.ToLower(key), strings.ToLower(path))
 }
 
+// IsKeyInPath reports whether the key is the path itself or one of its descendants.
+// Unlike HasPathInKey it compares whole path entities, so "/log" doesn't include "/logging".
+func IsKeyInPath(path, key string) bool {
+	path = PathCombine(path)
+	key = PathCombine(key)
+
+	if path == RootPath {
+		return true
+	}
+
+	if len(key) < len(path) || !strings.EqualFold(key[:len(path)], path) {
+		return false
+	}
+
+	return len(key) == len(path) || key[len(path):len(path)+1] == PathDelimiter
+}
+
 func GetPaths(key string) []string {
 	paths := make([]string, 0)
 	paths = append(paths, RootPath)
-	paths = append(NewStringSplitter(key).Split(PathDelimiter, true))
+	paths = append(paths, NewStringSplitter(key).Split(PathDelimiter, true)...)
 	return paths
 }
 
@@ -93,7 +112,7 @@ func FindChildKeys(basePath string, keys []string) []string {
 	var subKeys []string
 
 	for _, k := range keys {
-		if HasPathInKey(basePath, k) {
+		if IsKeyInPath(basePath, k) {
 			subKeys = append(subKeys, k)
 		}
 	}
@@ -113,7 +132,7 @@ func FindChildPairs(basePath string, pairs []KeyValuePair) []KeyValuePair {
 	var subPairs []KeyValuePair
 
 	for _, p := range pairs {
-		if HasPathInKey(basePath, p.Key) {
+		if IsKeyInPath(basePath, p.Key) {
 			subPairs = append(subPairs, p)
 		}
 	}
@@ -162,7 +181,7 @@ func IsArrayPath(path string, keys []string) bool {
 	}
 
 	for _, k := range keys {
-		if HasPathInKey(checkIdxString, k) {
+		if IsKeyInPath(checkIdxString, k) {
 			return true
 		}
 	}
@@ -241,3 +260,20 @@ func parseIndexOfArray(checkIdxString, key string) int {
 
 	return findIdx
 }
+
+func ParseArrayIndex(sectionKey string) (int, bool) {
+	if len(sectionKey) < 2 {
+		return -1, false
+	}
+
+	if sectionKey[:1] != ArrayDelimiter {
+		return -1, false
+	}
+
+	idx, err := strconv.Atoi(sectionKey[1:])
+	if err != nil || idx < 0 {
+		return -1, false
+	}
+
+	return idx, true
+}
@@ -0,0 +1,165 @@
+package gconf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+const DefaultPollingInterval = 2 * time.Second
+
+// PollingWatcher watches the file by comparing its modification time and size, and optionally
+// the hash of its contents, on every interval. It works on the filesystems which don't raise
+// the events of fsnotify such as NFS or FUSE mounts.
+type PollingWatcher struct {
+	filePath    string
+	interval    time.Duration
+	compareHash bool
+	changeToken ChangeToken
+	state       pollingState
+	isWatching  bool
+	done        chan struct{}
+	mutex       sync.Mutex
+}
+
+type pollingState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	hash    []byte
+}
+
+func NewPollingWatcher(filePath string, interval time.Duration) (*PollingWatcher, error) {
+	if filePath == "" {
+		return nil, errors.New("invalid filePath for polling watcher")
+	}
+
+	if interval <= 0 {
+		interval = DefaultPollingInterval
+	}
+
+	return &PollingWatcher{
+		filePath: filePath,
+		interval: interval,
+	}, nil
+}
+
+// SetCompareHash makes the watcher compare the hash of the contents as well,
+// which catches the changes keeping the same modification time and size.
+func (w *PollingWatcher) SetCompareHash(compareHash bool) *PollingWatcher {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.compareHash = compareHash
+	return w
+}
+
+func (w *PollingWatcher) GetInterval() time.Duration {
+	return w.interval
+}
+
+func (w *PollingWatcher) Watch(reloadToken ChangeToken) error {
+	if w.IsWatching() {
+		w.Close()
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.changeToken = reloadToken
+	w.state = w.readState()
+	w.done = make(chan struct{})
+	w.isWatching = true
+
+	// the goroutine gets its own token, Watch may replace the field while it is running
+	go w.polling(reloadToken, w.done)
+
+	return nil
+}
+
+func (w *PollingWatcher) polling(changeToken ChangeToken, done chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case <-ticker.C:
+			if w.poll() {
+				changeToken.OnChanged()
+			}
+		}
+	}
+}
+
+func (w *PollingWatcher) poll() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.isWatching {
+		return false
+	}
+
+	state := w.readState()
+	changed := !state.equals(w.state)
+	w.state = state
+
+	return changed
+}
+
+// readState must be called with the mutex held.
+func (w *PollingWatcher) readState() pollingState {
+	info, err := os.Stat(w.filePath)
+	if err != nil {
+		return pollingState{}
+	}
+
+	state := pollingState{
+		exists:  true,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+
+	if w.compareHash {
+		if stream, err := ioutil.ReadFile(w.filePath); err == nil {
+			hash := sha256.Sum256(stream)
+			state.hash = hash[:]
+		}
+	}
+
+	return state
+}
+
+func (s pollingState) equals(other pollingState) bool {
+	return s.exists == other.exists &&
+		s.modTime.Equal(other.modTime) &&
+		s.size == other.size &&
+		bytes.Equal(s.hash, other.hash)
+}
+
+func (w *PollingWatcher) IsWatching() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.isWatching
+}
+
+func (w *PollingWatcher) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.isWatching {
+		return nil
+	}
+
+	w.isWatching = false
+	close(w.done)
+
+	return nil
+}
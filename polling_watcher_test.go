@@ -0,0 +1,116 @@
+package gconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPollingWatcherSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"version": "1"}`)
+
+	root, err := NewConfBuilder().
+		Add(NewFileConfSource(path).
+			SetWatchMode(WatchModePolling).
+			SetPollingInterval(20 * time.Millisecond)).
+		Build()
+
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+	defer root.Dispose()
+
+	root.OnChange("", func(ConfChanges) {})
+
+	writeFileAtomic(t, path, `{"version": "22"}`)
+	waitForValue(t, root, "version", "22")
+}
+
+func TestPollingWatcherHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	write := func(content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("can't write the file: %s", err.Error())
+		}
+
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("can't change the modification time: %s", err.Error())
+		}
+	}
+
+	write(`{"version": "1"}`)
+
+	watcher, err := NewPollingWatcher(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("can't create the polling watcher: %s", err.Error())
+	}
+
+	token := NewChangeToken()
+	watcher.SetCompareHash(true).Watch(token)
+	defer watcher.Close()
+
+	write(`{"version": "2"}`)
+
+	deadline := time.Now().Add(time.Second)
+	for !token.HasChanged() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !token.HasChanged() {
+		t.Errorf("change of the contents with the same size and modification time wasn't detected")
+	}
+}
+
+func TestPollingWatcherRewatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatalf("can't create the temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.json")
+	writeFileAtomic(t, path, `{"version": "1"}`)
+
+	watcher, err := NewPollingWatcher(path, time.Millisecond)
+	if err != nil {
+		t.Fatalf("can't create the polling watcher: %s", err.Error())
+	}
+	defer watcher.Close()
+
+	var token ChangeToken
+
+	// the tokens are replaced while the previous goroutines may still be polling
+	for i := 0; i < 10; i++ {
+		token = NewChangeToken()
+		watcher.Watch(token)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	writeFileAtomic(t, path, `{"version": "22"}`)
+
+	deadline := time.Now().Add(time.Second)
+	for !token.HasChanged() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !token.HasChanged() {
+		t.Errorf("the token of the last Watch didn't change")
+	}
+}
@@ -0,0 +1,201 @@
+package gconf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	propertiesDelimiter = "."
+	propertiesComments  = "#!"
+)
+
+// propertiesFileFormat reads and writes the java properties, the arrays are written
+// as "servers[0].host" and every value is read as a string.
+type propertiesFileFormat struct{}
+
+func (propertiesFileFormat) Parse(stream []byte) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	scanner := bufio.NewScanner(bytes.NewReader(stream))
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.ContainsAny(line[:1], propertiesComments) {
+			continue
+		}
+
+		idx := indexPropertiesSeparator(line)
+		if idx == -1 {
+			return nil, errors.New(fmt.Sprintf("[propertiesFileFormat::Parse] missing separator at line %d", lineNo))
+		}
+
+		key := unescapeProperties(strings.TrimSpace(line[:idx]))
+		value := unescapeProperties(strings.TrimSpace(line[idx+1:]))
+
+		data[propertiesKeyToPath(key)] = value
+	}
+
+	return data, scanner.Err()
+}
+
+func (propertiesFileFormat) Encode(data map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+
+	sortPaths(keys)
+
+	var buf bytes.Buffer
+
+	for _, k := range keys {
+		value, err := valueToString(data[k])
+		if err != nil {
+			value = fmt.Sprint(data[k])
+		}
+
+		buf.WriteString(escapeProperties(pathToPropertiesKey(k), true))
+		buf.WriteString("=")
+		buf.WriteString(escapeProperties(value, false))
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func indexPropertiesSeparator(line string) int {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return i
+		}
+	}
+
+	return -1
+}
+
+func propertiesKeyToPath(key string) string {
+	entities := []string{RootPath}
+
+	for _, e := range strings.Split(key, propertiesDelimiter) {
+		for {
+			open := strings.Index(e, "[")
+			if open == -1 || !strings.HasSuffix(e, "]") {
+				break
+			}
+
+			if open > 0 {
+				entities = append(entities, e[:open])
+			}
+
+			for _, idx := range strings.Split(strings.Trim(e[open:], "[]"), "][") {
+				entities = append(entities, ArrayDelimiter+idx)
+			}
+
+			e = ""
+		}
+
+		if e != "" {
+			entities = append(entities, e)
+		}
+	}
+
+	return PathCombine(entities...)
+}
+
+func pathToPropertiesKey(path string) string {
+	var buf bytes.Buffer
+
+	for _, e := range NewStringSplitter(path).Split(PathDelimiter, true) {
+		if idx, ok := ParseArrayIndex(e); ok {
+			buf.WriteString("[" + strconv.Itoa(idx) + "]")
+			continue
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteString(propertiesDelimiter)
+		}
+
+		buf.WriteString(e)
+	}
+
+	return buf.String()
+}
+
+func escapeProperties(s string, isKey bool) string {
+	replacements := []string{"\\", "\\\\", "\n", "\\n", "\r", "\\r", "\t", "\\t"}
+
+	if isKey {
+		replacements = append(replacements, "=", "\\=", ":", "\\:", " ", "\\ ")
+	}
+
+	return strings.NewReplacer(replacements...).Replace(s)
+}
+
+func unescapeProperties(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var buf bytes.Buffer
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+
+		i++
+
+		switch s[i] {
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+
+	return buf.String()
+}
+
+// sortPaths sorts the paths by their entities, the array indexes are compared as numbers
+// so "$2" comes before "$10".
+func sortPaths(paths []string) {
+	sort.Slice(paths, func(i, j int) bool {
+		return comparePaths(paths[i], paths[j]) < 0
+	})
+}
+
+func comparePaths(a string, b string) int {
+	ae := NewStringSplitter(a).Split(PathDelimiter, true)
+	be := NewStringSplitter(b).Split(PathDelimiter, true)
+
+	for i := 0; i < len(ae) && i < len(be); i++ {
+		ai, aIsIndex := ParseArrayIndex(ae[i])
+		bi, bIsIndex := ParseArrayIndex(be[i])
+
+		switch {
+		case aIsIndex && bIsIndex && ai != bi:
+			if ai < bi {
+				return -1
+			}
+			return 1
+		case ae[i] != be[i]:
+			return strings.Compare(ae[i], be[i])
+		}
+	}
+
+	return len(ae) - len(be)
+}
@@ -8,15 +8,20 @@ import (
 func main() {
 
 	envSource := gconf.NewEnvConfSource()
-	jsonSource := gconf.NewJsonFileConfSource("real.json")
-	yamlSource := gconf.NewJsonFileConfSource("real.yml")
+	jsonSource := gconf.NewFileConfSource("real.json")
+	yamlSource := gconf.NewFileConfSource("real.yml")
 
-	conf := gconf.NewConfBuilder().
+	conf, err := gconf.NewConfBuilder().
 		Add(envSource).
 		Add(jsonSource).
 		Add(yamlSource).
 		Build()
 
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
 	pairs := conf.ToKeyValuePairs()
 	for _, p := range pairs {
 		fmt.Printf("Key: %v, Value: %v\n", p.Key, p.Value)
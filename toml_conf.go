@@ -23,7 +23,7 @@ func (s *TomlConfSource) Build(confBuilder ConfBuilder) (ConfProvider, error) {
 }
 
 func (s *TomlConfSource) Load() (map[string]interface{}, error) {
-	parser := newJsonConfParser(RootPath, PathDelimiter)
+	parser := newTomlConfParser(RootPath, PathDelimiter)
 	err := parser.Parse(s.tomlMessage)
 
 	if err != nil {
@@ -49,7 +49,7 @@ func newTomlConfParser(rootPath string, keyDelimiter string) *tomlConfParser {
 
 func (p *tomlConfParser) Parse(stream []byte) error {
 	if stream == nil || len(stream) == 0 {
-		return errors.New("[jsonConfParser::Parse] invalid null argument: stream")
+		return errors.New("[tomlConfParser::Parse] invalid null argument: stream")
 	}
 
 	var data interface{}
@@ -88,15 +88,12 @@ func (p *tomlConfParser) parseArray(raw interface{}, parentKey string) {
 		return
 	}
 
-	data, ok := raw.([]interface{})
+	// the arrays of tables are decoded as []map[string]interface{}
+	data := reflect.ValueOf(raw)
 
-	if !ok {
-		return
-	}
-
-	for idx, v := range data {
-		newPath := PathCombine(parentKey, ArrayDelimiter+ fmt.Sprint(idx))
-		p.parse(v, newPath)
+	for idx := 0; idx < data.Len(); idx++ {
+		newPath := PathCombine(parentKey, ArrayDelimiter+fmt.Sprint(idx))
+		p.parse(data.Index(idx).Interface(), newPath)
 	}
 }
 
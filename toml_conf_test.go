@@ -0,0 +1,42 @@
+package gconf
+
+import "testing"
+
+func TestTomlConfSource(t *testing.T) {
+	toml := []byte(`
+title = "gconf"
+ports = [80, 443]
+
+[database]
+host = "db.local"
+
+[[servers]]
+host = "alpha"
+
+[[servers]]
+host = "beta"
+`)
+
+	root, err := NewConfBuilder().Add(NewTomlConfSource(toml)).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	expected := map[string]string{
+		"title":           "gconf",
+		"ports/$1":        "443",
+		"database/host":   "db.local",
+		"servers/$0/host": "alpha",
+		"servers/$1/host": "beta",
+	}
+
+	for key, value := range expected {
+		if returned := root.TryGetString(key, ""); returned != value {
+			t.Errorf("value of the key[%s], expected: %s, returned: %s", key, value, returned)
+		}
+	}
+
+	if _, err := NewConfBuilder().Add(NewTomlConfSource(nil)).Build(); err == nil {
+		t.Errorf("build of the empty toml, expected: an error, returned: nil")
+	}
+}
@@ -36,13 +36,23 @@ func NewTypeConverter(confBase ConfBase) TypeConverter {
 	}
 }
 
+// getValue returns the value of the key, the references of the value are resolved
+// when the configuration supports the interpolation.
+func (t *TypeConverter) getValue(key string) (interface{}, error) {
+	return resolveValue(t.confBase, key)
+}
+
 func (t *TypeConverter) GetInt(key string) (int, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultInt32, errorCantFindKey
+	if err != nil {
+		return defaultInt32, err
 	}
 
+	return valueToInt(value)
+}
+
+func valueToInt(value interface{}) (int, error) {
 	v := reflect.ValueOf(value)
 
 	switch v.Kind() {
@@ -60,12 +70,16 @@ func (t *TypeConverter) GetInt(key string) (int, error) {
 }
 
 func (t *TypeConverter) GetInt64(key string) (int64, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultInt64, errorCantFindKey
+	if err != nil {
+		return defaultInt64, err
 	}
 
+	return valueToInt64(value)
+}
+
+func valueToInt64(value interface{}) (int64, error) {
 	v := reflect.ValueOf(value)
 
 	switch v.Kind() {
@@ -83,12 +97,16 @@ func (t *TypeConverter) GetInt64(key string) (int64, error) {
 }
 
 func (t *TypeConverter) GetUint(key string) (uint, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultUint32, errorCantFindKey
+	if err != nil {
+		return defaultUint32, err
 	}
 
+	return valueToUint(value)
+}
+
+func valueToUint(value interface{}) (uint, error) {
 	v := reflect.ValueOf(value)
 
 	switch v.Kind() {
@@ -106,12 +124,16 @@ func (t *TypeConverter) GetUint(key string) (uint, error) {
 }
 
 func (t *TypeConverter) GetUint64(key string) (uint64, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultUint64, errorCantFindKey
+	if err != nil {
+		return defaultUint64, err
 	}
 
+	return valueToUint64(value)
+}
+
+func valueToUint64(value interface{}) (uint64, error) {
 	v := reflect.ValueOf(value)
 
 	switch v.Kind() {
@@ -129,12 +151,16 @@ func (t *TypeConverter) GetUint64(key string) (uint64, error) {
 }
 
 func (t *TypeConverter) GetFloat32(key string) (float32, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultFloat32, errorCantFindKey
+	if err != nil {
+		return defaultFloat32, err
 	}
 
+	return valueToFloat32(value)
+}
+
+func valueToFloat32(value interface{}) (float32, error) {
 	v := reflect.ValueOf(value)
 	switch v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -151,12 +177,16 @@ func (t *TypeConverter) GetFloat32(key string) (float32, error) {
 }
 
 func (t *TypeConverter) GetFloat64(key string) (float64, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultFloat64, errorCantFindKey
+	if err != nil {
+		return defaultFloat64, err
 	}
 
+	return valueToFloat64(value)
+}
+
+func valueToFloat64(value interface{}) (float64, error) {
 	v := reflect.ValueOf(value)
 	switch v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -173,12 +203,16 @@ func (t *TypeConverter) GetFloat64(key string) (float64, error) {
 }
 
 func (t *TypeConverter) GetComplex64(key string) (complex64, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultComplex64, errorCantFindKey
+	if err != nil {
+		return defaultComplex64, err
 	}
 
+	return valueToComplex64(value)
+}
+
+func valueToComplex64(value interface{}) (complex64, error) {
 	v := reflect.ValueOf(value)
 	switch v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -196,12 +230,16 @@ func (t *TypeConverter) GetComplex64(key string) (complex64, error) {
 }
 
 func (t *TypeConverter) GetComplex128(key string) (complex128, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultComplex128, errorCantFindKey
+	if err != nil {
+		return defaultComplex128, err
 	}
 
+	return valueToComplex128(value)
+}
+
+func valueToComplex128(value interface{}) (complex128, error) {
 	v := reflect.ValueOf(value)
 	switch v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -219,12 +257,16 @@ func (t *TypeConverter) GetComplex128(key string) (complex128, error) {
 }
 
 func (t *TypeConverter) GetByte(key string) (byte, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultByte, errorCantFindKey
+	if err != nil {
+		return defaultByte, err
 	}
 
+	return valueToByte(value)
+}
+
+func valueToByte(value interface{}) (byte, error) {
 	v := reflect.ValueOf(value)
 
 	switch v.Kind() {
@@ -242,12 +284,16 @@ func (t *TypeConverter) GetByte(key string) (byte, error) {
 }
 
 func (t *TypeConverter) GetBoolean(key string) (bool, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultBool, errorCantFindKey
+	if err != nil {
+		return defaultBool, err
 	}
 
+	return valueToBoolean(value)
+}
+
+func valueToBoolean(value interface{}) (bool, error) {
 	v := reflect.ValueOf(value)
 
 	switch v.Kind() {
@@ -261,12 +307,22 @@ func (t *TypeConverter) GetBoolean(key string) (bool, error) {
 }
 
 func (t *TypeConverter) GetString(key string) (string, error) {
-	value := t.confBase.Get(key)
+	value, err := t.getValue(key)
 
-	if value == nil {
-		return defaultString, errorCantFindKey
+	if err != nil {
+		return defaultString, err
 	}
 
+	return valueToString(value)
+}
+
+// GetValue returns the value of the key with its references resolved, unlike Get it returns
+// the error when the key doesn't exist or a reference can't be resolved.
+func (t *TypeConverter) GetValue(key string) (interface{}, error) {
+	return t.getValue(key)
+}
+
+func valueToString(value interface{}) (string, error) {
 	v := reflect.ValueOf(value)
 
 	switch v.Kind() {
@@ -285,6 +341,15 @@ func (t *TypeConverter) GetString(key string) (string, error) {
 	return defaultString, errorCantConvert
 }
 
+// scalarToString is valueToString which also accepts the booleans, GetString keeps refusing them.
+func scalarToString(value interface{}) (string, error) {
+	if b, ok := value.(bool); ok {
+		return BooleanToString(b), nil
+	}
+
+	return valueToString(value)
+}
+
 func (t *TypeConverter) TryGetInt(key string, defaultValue int) int {
 	v, err := t.GetInt(key)
 
@@ -0,0 +1,323 @@
+package gconf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	unmarshalTagName = "gconf"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Unmarshal binds the section of the key into out, which must be a non-nil pointer.
+// Struct fields are matched case-insensitively by their name or by the `gconf:"name"` tag,
+// `gconf:"-"` skips the field. Arrays ($N paths) are bound to slices and arrays,
+// the other sections to structs and maps. The keys which don't exist leave out untouched.
+func (t *TypeConverter) Unmarshal(key string, out interface{}) error {
+	if out == nil {
+		return errors.New("[TypeConverter::Unmarshal] invalid null argument: out")
+	}
+
+	rv := reflect.ValueOf(out)
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New(fmt.Sprintf("[TypeConverter::Unmarshal] invalid argument: out must be a non-nil pointer, not %s", rv.Type()))
+	}
+
+	path := PathCombine(t.confBase.GetPath(), key)
+	node := buildConfTree(path, t.confBase.ToKeyValuePairs())
+
+	if node == nil {
+		return nil
+	}
+
+	return unmarshalNode(node, rv.Elem())
+}
+
+func unmarshalNode(node *confNode, v reflect.Value) error {
+	// a null leaf is a tombstone of the provider, it leaves the field untouched like a missing key
+	if node.hasValue && node.value == nil && !node.hasChildren() {
+		return nil
+	}
+
+	if v.Type() == durationType {
+		return unmarshalDuration(node, v)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return unmarshalNode(node, v.Elem())
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return newUnmarshalError(node, v, errorCantConvert)
+		}
+
+		if value := node.toValue(); value != nil {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	case reflect.Struct:
+		return unmarshalStruct(node, v)
+	case reflect.Map:
+		return unmarshalMap(node, v)
+	case reflect.Slice:
+		return unmarshalSlice(node, v)
+	case reflect.Array:
+		return unmarshalArray(node, v)
+	}
+
+	if !node.hasValue {
+		return nil
+	}
+
+	return unmarshalScalar(node, v)
+}
+
+func unmarshalStruct(node *confNode, v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		name := field.Tag.Get(unmarshalTagName)
+		if idx := strings.Index(name, ","); idx != -1 {
+			name = name[:idx]
+		}
+
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			if err := unmarshalEmbedded(node, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if field.PkgPath != "" || !fv.CanSet() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		child := node.child(name)
+		if child == nil {
+			continue
+		}
+
+		if err := unmarshalNode(child, fv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unmarshalEmbedded(node *confNode, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		return unmarshalStruct(node, v)
+	case reflect.Ptr:
+		if !v.CanSet() || v.Type().Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		return unmarshalNode(node, v)
+	}
+
+	return nil
+}
+
+func unmarshalMap(node *confNode, v reflect.Value) error {
+	t := v.Type()
+
+	if t.Key().Kind() != reflect.String {
+		return newUnmarshalError(node, v, errors.New("the key of the map must be a string"))
+	}
+
+	if !node.hasChildren() {
+		return nil
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+
+	for _, c := range node.children {
+		elem := reflect.New(t.Elem()).Elem()
+
+		if err := unmarshalNode(c, elem); err != nil {
+			return err
+		}
+
+		v.SetMapIndex(reflect.ValueOf(c.name).Convert(t.Key()), elem)
+	}
+
+	return nil
+}
+
+func unmarshalSlice(node *confNode, v reflect.Value) error {
+	if !node.hasChildren() {
+		if node.hasValue && v.Type().Elem().Kind() == reflect.Uint8 {
+			s, err := scalarToString(node.value)
+			if err != nil {
+				return newUnmarshalError(node, v, err)
+			}
+
+			v.SetBytes([]byte(s))
+		}
+		return nil
+	}
+
+	if !node.isArray() {
+		return newUnmarshalError(node, v, errors.New("the section is not an array"))
+	}
+
+	length := node.arrayLength()
+	slice := reflect.MakeSlice(v.Type(), length, length)
+
+	for _, c := range node.children {
+		idx, _ := ParseArrayIndex(c.name)
+
+		if err := unmarshalNode(c, slice.Index(idx)); err != nil {
+			return err
+		}
+	}
+
+	v.Set(slice)
+	return nil
+}
+
+func unmarshalArray(node *confNode, v reflect.Value) error {
+	if !node.hasChildren() {
+		return nil
+	}
+
+	if !node.isArray() {
+		return newUnmarshalError(node, v, errors.New("the section is not an array"))
+	}
+
+	for _, c := range node.children {
+		idx, _ := ParseArrayIndex(c.name)
+
+		if idx >= v.Len() {
+			continue
+		}
+
+		if err := unmarshalNode(c, v.Index(idx)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unmarshalDuration(node *confNode, v reflect.Value) error {
+	if !node.hasValue {
+		return nil
+	}
+
+	if s, ok := node.value.(string); ok {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return newUnmarshalError(node, v, err)
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	return unmarshalScalar(node, v)
+}
+
+func unmarshalScalar(node *confNode, v reflect.Value) error {
+	var err error
+
+	switch v.Kind() {
+	case reflect.Bool:
+		var b bool
+		if b, err = valueToBoolean(node.value); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if err = checkIntegral(node.value); err != nil {
+			break
+		}
+		if i, err = valueToInt64(node.value); err == nil {
+			if v.OverflowInt(i) {
+				err = errors.New(fmt.Sprintf("the value %d overflows", i))
+			} else {
+				v.SetInt(i)
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		if err = checkIntegral(node.value); err != nil {
+			break
+		}
+		if u, err = valueToUint64(node.value); err == nil {
+			if v.OverflowUint(u) {
+				err = errors.New(fmt.Sprintf("the value %d overflows", u))
+			} else {
+				v.SetUint(u)
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = valueToFloat64(node.value); err == nil {
+			v.SetFloat(f)
+		}
+	case reflect.Complex64, reflect.Complex128:
+		var c complex128
+		if c, err = valueToComplex128(node.value); err == nil {
+			v.SetComplex(c)
+		}
+	case reflect.String:
+		var s string
+		if s, err = scalarToString(node.value); err == nil {
+			v.SetString(s)
+		}
+	default:
+		err = errorCantConvert
+	}
+
+	if err != nil {
+		return newUnmarshalError(node, v, err)
+	}
+
+	return nil
+}
+
+// checkIntegral refuses the floats with a fraction, which the integer conversions truncate.
+func checkIntegral(value interface{}) error {
+	v := reflect.ValueOf(value)
+
+	if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+		return nil
+	}
+
+	if f := v.Float(); f != math.Trunc(f) {
+		return errors.New(fmt.Sprintf("the value %v has a fraction", value))
+	}
+
+	return nil
+}
+
+func newUnmarshalError(node *confNode, v reflect.Value, err error) error {
+	return errors.New(fmt.Sprintf("[TypeConverter::Unmarshal] can't bind the key[%s] to %s: %s", node.path, v.Type(), err.Error()))
+}
@@ -0,0 +1,162 @@
+package gconf
+
+import (
+	"testing"
+	"time"
+)
+
+type testEndpoint struct {
+	Host string
+	Port int `gconf:"port"`
+}
+
+type testCommon struct {
+	Name string
+}
+
+type testSettings struct {
+	testCommon
+	Enabled  bool
+	Timeout  time.Duration
+	Primary  *testEndpoint
+	Servers  []testEndpoint
+	Tags     map[string]string
+	Ignored  string `gconf:"-"`
+	Ratio    float32
+	Optional *int
+}
+
+func TestUnmarshal(t *testing.T) {
+	json := []byte(`{
+		"settings": {
+			"name": "gconf",
+			"enabled": "true",
+			"timeout": "1m30s",
+			"primary": { "host": "primary.local", "port": "8080" },
+			"servers": [
+				{ "host": "a.local", "port": 1 },
+				{ "host": "b.local", "port": 2 }
+			],
+			"tags": { "env": "prod", "zone": "kr" },
+			"ignored": "value",
+			"ratio": 0.5
+		}
+	}`)
+
+	conf, err := NewConfBuilder().Add(NewJsonConfSource(json)).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	var s testSettings
+	if err := conf.Unmarshal("settings", &s); err != nil {
+		t.Fatalf("unmarshal returned an error: %s", err.Error())
+	}
+
+	if s.Name != "gconf" {
+		t.Errorf("unmarshal embedded struct, expected: %s, returned: %s", "gconf", s.Name)
+	}
+
+	if !s.Enabled {
+		t.Errorf("unmarshal bool from string, expected: %v, returned: %v", true, s.Enabled)
+	}
+
+	if s.Timeout != 90*time.Second {
+		t.Errorf("unmarshal duration, expected: %v, returned: %v", 90*time.Second, s.Timeout)
+	}
+
+	if s.Primary == nil || s.Primary.Host != "primary.local" || s.Primary.Port != 8080 {
+		t.Errorf("unmarshal pointer to struct, returned: %+v", s.Primary)
+	}
+
+	if len(s.Servers) != 2 || s.Servers[1].Host != "b.local" || s.Servers[1].Port != 2 {
+		t.Errorf("unmarshal slice of struct, returned: %+v", s.Servers)
+	}
+
+	if s.Tags["zone"] != "kr" || len(s.Tags) != 2 {
+		t.Errorf("unmarshal map, returned: %v", s.Tags)
+	}
+
+	if s.Ignored != "" {
+		t.Errorf("unmarshal skipped field, expected: empty, returned: %s", s.Ignored)
+	}
+
+	if s.Ratio != 0.5 {
+		t.Errorf("unmarshal float, expected: %v, returned: %v", 0.5, s.Ratio)
+	}
+
+	if s.Optional != nil {
+		t.Errorf("unmarshal missing pointer, expected: nil, returned: %v", *s.Optional)
+	}
+
+	var endpoint testEndpoint
+	if err := conf.GetSection("settings").Unmarshal("primary", &endpoint); err != nil {
+		t.Fatalf("unmarshal on section returned an error: %s", err.Error())
+	}
+
+	if endpoint.Port != 8080 {
+		t.Errorf("unmarshal on section, expected: %d, returned: %d", 8080, endpoint.Port)
+	}
+
+	if err := conf.Unmarshal("settings", s); err == nil {
+		t.Errorf("unmarshal into a non-pointer value must return an error")
+	}
+
+	var invalid struct{ Port int }
+	if err := conf.Unmarshal("settings/primary/host", &invalid.Port); err == nil {
+		t.Errorf("unmarshal of an invalid number must return an error")
+	}
+
+	if err := conf.Unmarshal("settings/ratio", &invalid.Port); err == nil {
+		t.Errorf("unmarshal of a fraction into an int must return an error, returned: %d", invalid.Port)
+	}
+
+	if _, err := conf.GetString("settings/enabled"); err != nil {
+		t.Errorf("get string of a string value returned an error: %s", err.Error())
+	}
+}
+
+func TestUnmarshalBoolean(t *testing.T) {
+	conf, err := NewConfBuilder().Add(NewJsonConfSource([]byte(`{ "flag": true, "count": 2.0 }`))).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	var s struct {
+		Flag  string
+		Count int
+	}
+
+	if err := conf.Unmarshal("", &s); err != nil {
+		t.Fatalf("unmarshal returned an error: %s", err.Error())
+	}
+
+	if s.Flag != "true" || s.Count != 2 {
+		t.Errorf("unmarshal of the bool into a string, expected: {true 2}, returned: %+v", s)
+	}
+
+	if _, err := conf.GetString("flag"); err == nil {
+		t.Errorf("get string of a bool value, expected: an error, returned: nil")
+	}
+}
+
+func TestUnmarshalNull(t *testing.T) {
+	conf, err := NewConfBuilder().Add(NewJsonConfSource([]byte(`{ "port": null, "host": "alpha", "timeout": null }`))).Build()
+	if err != nil {
+		t.Fatalf("can't build the configuration: %s", err.Error())
+	}
+
+	s := struct {
+		Port    int
+		Host    string
+		Timeout *time.Duration
+	}{Port: 80}
+
+	if err := getProviders(conf)[0].Unmarshal("", &s); err != nil {
+		t.Fatalf("unmarshal of the null keys returned an error: %s", err.Error())
+	}
+
+	if s.Port != 80 || s.Host != "alpha" || s.Timeout != nil {
+		t.Errorf("unmarshal of the null keys, expected: {80 alpha <nil>}, returned: %+v", s)
+	}
+}
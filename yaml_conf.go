@@ -1,12 +1,6 @@
 package gconf
 
-import (
-	"errors"
-	"fmt"
-	"reflect"
-
-	"gopkg.in/yaml.v2"
-)
+import "errors"
 
 type YamlConfSource struct {
 	yamlMessage []byte
@@ -52,72 +46,21 @@ func (p *yamlConfParser) Parse(stream []byte) error {
 		return errors.New("[yamlConfParser::Parse] invalid null argument: stream")
 	}
 
-	var data interface{}
-
-	if err := yaml.Unmarshal(stream, &data); err != nil {
+	doc, err := parseYamlDocument(stream)
+	if err != nil {
 		return err
 	}
 
-	p.parse(data, p.rootPath)
-
-	return nil
-}
-
-func (p *yamlConfParser) parse(value interface{}, path string) {
-	if value == nil {
-		return
-	}
-
-	rv := reflect.ValueOf(value)
-
-	switch rv.Kind() {
-	case reflect.Map:
-		p.parseMap(value, path)
-		break
-	case reflect.Slice:
-		p.parseArray(value, path)
-		break
-	case reflect.Array:
-		p.parseArray(value, path)
-		break
-	default:
-		p.dataMap[path] = value
-		break
-	}
-}
-
-func (p *yamlConfParser) parseArray(raw interface{}, parentKey string) {
-	if raw == nil {
-		return
-	}
-
-	data, ok := raw.([]interface{})
-
-	if !ok {
-		return
-	}
-
-	for idx, v := range data {
-		newPath := PathCombine(parentKey, ArrayDelimiter+ fmt.Sprint(idx))
-		p.parse(v, newPath)
-	}
-}
-
-func (p *yamlConfParser) parseMap(raw interface{}, parentKey string) {
-	if raw == nil {
-		return
-	}
-
-	data, ok := raw.(map[interface{}]interface{})
-
-	if !ok {
-		return
+	data, err := doc.Flatten(p.rootPath)
+	if err != nil {
+		return err
 	}
 
 	for k, v := range data {
-		newPath := PathCombine(parentKey, fmt.Sprint(k))
-		p.parse(v, newPath)
+		p.dataMap[k] = v
 	}
+
+	return nil
 }
 
 func (p *yamlConfParser) GetDataMap() map[string]interface{} {
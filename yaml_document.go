@@ -0,0 +1,734 @@
+package gconf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	yamlMergeTag          = "!!merge"
+	yamlDefaultIndent     = 2
+	maxYamlPatchPasses    = 3
+	yamlMaxIndent         = 9
+	yamlNullTag           = "!!null"
+	yamlMappingTag        = "!!map"
+	yamlSequenceTag       = "!!seq"
+	yamlStringTag         = "!!str"
+	yamlNullPlaceholder   = "null"
+	yamlWindowsLineEnding = "\r\n"
+)
+
+// yaml11Booleans are the plain scalars yaml 1.1 resolves as booleans, yaml.v3 follows yaml 1.2
+// and loads them as strings, they are kept as booleans so the existing files don't change meaning.
+var yaml11Booleans = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true, "on": true, "On": true, "ON": true,
+	"n": false, "N": false, "no": false, "No": false, "NO": false, "off": false, "Off": false, "OFF": false,
+}
+
+// yamlDocument keeps the node tree of a yaml document along with its source, so the values
+// can be updated without losing the comments, the order of the keys and the anchors.
+// The scalars written on a single line are replaced in the source, so the rest of the document
+// stays byte-identical; the other updates re-encode the node tree.
+type yamlDocument struct {
+	stream []byte
+	root   *yaml.Node
+}
+
+// yamlSlot is the place of a node in its parent, the node is parent.Content[index].
+type yamlSlot struct {
+	parent *yaml.Node
+	index  int
+}
+
+type yamlPair struct {
+	key    *yaml.Node
+	value  *yaml.Node
+	merged bool
+}
+
+func parseYamlDocument(stream []byte) (*yamlDocument, error) {
+	d := &yamlDocument{}
+
+	if err := d.reset(stream); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *yamlDocument) reset(stream []byte) error {
+	var root yaml.Node
+
+	if err := yaml.Unmarshal(stream, &root); err != nil {
+		return err
+	}
+
+	d.stream = stream
+	d.root = &root
+
+	return nil
+}
+
+func (d *yamlDocument) Bytes() []byte {
+	return d.stream
+}
+
+// body returns the top level node of the document, it returns nil for an empty document.
+func (d *yamlDocument) body() *yaml.Node {
+	if d.root.Kind != yaml.DocumentNode || len(d.root.Content) == 0 {
+		return nil
+	}
+
+	return d.root.Content[0]
+}
+
+// Flatten returns the flattened key/value map of the document, the aliases are resolved
+// and the merged keys are added unless they are declared explicitly.
+func (d *yamlDocument) Flatten(rootPath string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+
+	if body := d.body(); body != nil {
+		if err := flattenYamlNode(body, rootPath, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func flattenYamlNode(node *yaml.Node, path string, data map[string]interface{}) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return flattenYamlNode(node.Alias, path, data)
+	case yaml.MappingNode:
+		for _, p := range yamlMappingPairs(node) {
+			if err := flattenYamlNode(p.value, PathCombine(path, p.key.Value), data); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for idx, item := range node.Content {
+			if err := flattenYamlNode(item, GetArrayIndexPath(path, idx), data); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		var value interface{}
+
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+
+		if b, ok := yaml11Booleans[node.Value]; ok && node.Style == 0 && node.ShortTag() == yamlStringTag {
+			value = b
+		}
+
+		// the nulls are kept, they remove the key from the merged view of the root
+		if value != nil || path != RootPath {
+			data[path] = value
+		}
+	}
+
+	return nil
+}
+
+// yamlMappingPairs returns the pairs of the mapping in the order of the document,
+// followed by the merged pairs which aren't declared explicitly.
+func yamlMappingPairs(node *yaml.Node) []yamlPair {
+	pairs := make([]yamlPair, 0, len(node.Content)/2)
+	declared := make(map[string]bool)
+	merges := make([]*yaml.Node, 0)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k := node.Content[i]
+		v := node.Content[i+1]
+
+		if isYamlMergeKey(k) {
+			merges = append(merges, v)
+			continue
+		}
+
+		declared[k.Value] = true
+		pairs = append(pairs, yamlPair{key: k, value: v})
+	}
+
+	for _, m := range merges {
+		m = resolveYamlAlias(m)
+
+		sources := []*yaml.Node{m}
+		if m.Kind == yaml.SequenceNode {
+			sources = m.Content
+		}
+
+		for _, s := range sources {
+			s = resolveYamlAlias(s)
+
+			if s.Kind != yaml.MappingNode {
+				continue
+			}
+
+			for _, p := range yamlMappingPairs(s) {
+				if declared[p.key.Value] {
+					continue
+				}
+
+				declared[p.key.Value] = true
+				pairs = append(pairs, yamlPair{key: p.key, value: p.value, merged: true})
+			}
+		}
+	}
+
+	return pairs
+}
+
+func isYamlMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Value == "<<" && node.Tag == yamlMergeTag
+}
+
+func resolveYamlAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	return node
+}
+
+// Set sets the value of the key, the missing mappings and sequences of the key are created.
+// The key which is defined by an alias or a merge key gets its own copy, so the anchor is left untouched.
+func (d *yamlDocument) Set(key string, value interface{}) error {
+	d.ensureBody()
+
+	slots, changed, err := d.locate(key, true)
+	if err != nil {
+		return err
+	}
+
+	slot := slots[len(slots)-1]
+	old := slot.parent.Content[slot.index]
+
+	node, err := newYamlValueNode(value, old)
+	if err != nil {
+		return errors.New(fmt.Sprintf("[yamlDocument::Set] can't encode the value of the key[%s]: %s", key, err.Error()))
+	}
+
+	if !changed {
+		if stream, ok := d.splice(old, node); ok {
+			return d.reset(stream)
+		}
+	}
+
+	node.Anchor = old.Anchor
+	node.HeadComment = old.HeadComment
+	node.LineComment = old.LineComment
+	node.FootComment = old.FootComment
+	slot.parent.Content[slot.index] = node
+
+	return d.encode()
+}
+
+// Remove removes the key, the elements of the sequence which follow the removed one are shifted
+// and the mappings and sequences left empty are removed as well.
+func (d *yamlDocument) Remove(key string) error {
+	if d.body() == nil {
+		return errors.New(fmt.Sprintf("[yamlDocument::Remove] the key doesn't exist: %s", key))
+	}
+
+	slots, _, err := d.locate(key, false)
+	if err != nil {
+		return err
+	}
+
+	for i := len(slots) - 1; i > 0; i-- {
+		removeYamlSlot(slots[i])
+
+		if len(slots[i].parent.Content) != 0 {
+			break
+		}
+	}
+
+	return d.encode()
+}
+
+func (d *yamlDocument) ensureBody() {
+	if d.body() != nil {
+		return
+	}
+
+	d.root = &yaml.Node{
+		Kind:    yaml.DocumentNode,
+		Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: yamlMappingTag}},
+	}
+}
+
+// locate returns the slots from the body of the document down to the node of the key.
+// The aliases on the way are replaced with copies of their anchors, and when create is set
+// the missing nodes are added. It reports whether the node tree was changed.
+func (d *yamlDocument) locate(key string, create bool) ([]yamlSlot, bool, error) {
+	entities := NewStringSplitter(PathCombine(key)).Split(PathDelimiter, true)
+
+	if len(entities) == 0 {
+		return nil, false, errors.New("[yamlDocument::locate] the key of the root can't be updated")
+	}
+
+	slots := []yamlSlot{{parent: d.root, index: 0}}
+	changed := false
+
+	for i, e := range entities {
+		slot := slots[len(slots)-1]
+		node := slot.parent.Content[slot.index]
+
+		if node.Kind == yaml.AliasNode {
+			node = copyYamlNode(resolveYamlAlias(node))
+			node.Anchor = ""
+			slot.parent.Content[slot.index] = node
+			changed = true
+		}
+
+		_, isIndex := ParseArrayIndex(e)
+
+		if create && node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode {
+			node = newYamlContainerNode(isIndex)
+			slot.parent.Content[slot.index] = node
+			changed = true
+		}
+
+		placeholder := &yaml.Node{Kind: yaml.ScalarNode, Tag: yamlNullTag, Value: yamlNullPlaceholder}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			if idx := findYamlMappingKey(node, e); idx != -1 {
+				slots = append(slots, yamlSlot{parent: node, index: idx + 1})
+				continue
+			}
+
+			var merged *yamlPair
+
+			for _, p := range yamlMappingPairs(node) {
+				if p.merged && p.key.Value == e {
+					merged = &p
+					break
+				}
+			}
+
+			if merged != nil && !create {
+				return nil, false, errors.New(fmt.Sprintf("[yamlDocument::locate] the key[%s] is merged from an anchor and can't be removed", key))
+			}
+
+			if merged == nil && !create {
+				return nil, false, errors.New(fmt.Sprintf("[yamlDocument::locate] the key doesn't exist: %s", key))
+			}
+
+			value := placeholder
+			if merged != nil {
+				value = copyYamlNode(merged.value)
+				value.Anchor = ""
+			}
+
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: yamlStringTag, Value: e}, value)
+			slots = append(slots, yamlSlot{parent: node, index: len(node.Content) - 1})
+			changed = true
+		case yaml.SequenceNode:
+			idx, ok := ParseArrayIndex(e)
+
+			switch {
+			case !ok:
+				return nil, false, errors.New(fmt.Sprintf("[yamlDocument::locate] the entity[%s] of the key[%s] isn't an array index", e, key))
+			case idx < len(node.Content):
+				slots = append(slots, yamlSlot{parent: node, index: idx})
+			case idx == len(node.Content) && create:
+				node.Content = append(node.Content, placeholder)
+				slots = append(slots, yamlSlot{parent: node, index: idx})
+				changed = true
+			default:
+				return nil, false, errors.New(fmt.Sprintf("[yamlDocument::locate] the array index of the key[%s] is out of range", key))
+			}
+		default:
+			return nil, false, errors.New(fmt.Sprintf("[yamlDocument::locate] the key doesn't exist: %s", PathCombine(entities[:i+1]...)))
+		}
+	}
+
+	return slots, changed, nil
+}
+
+func findYamlMappingKey(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !isYamlMergeKey(node.Content[i]) && node.Content[i].Value == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func removeYamlSlot(slot yamlSlot) {
+	content := slot.parent.Content
+
+	if slot.parent.Kind == yaml.MappingNode {
+		slot.parent.Content = append(content[:slot.index-1], content[slot.index+1:]...)
+		return
+	}
+
+	slot.parent.Content = append(content[:slot.index], content[slot.index+1:]...)
+}
+
+func newYamlContainerNode(isArray bool) *yaml.Node {
+	if isArray {
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: yamlSequenceTag}
+	}
+
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: yamlMappingTag}
+}
+
+func copyYamlNode(node *yaml.Node) *yaml.Node {
+	c := *node
+
+	if node.Content != nil {
+		c.Content = make([]*yaml.Node, len(node.Content))
+
+		for i, child := range node.Content {
+			c.Content[i] = copyYamlNode(child)
+		}
+	}
+
+	return &c
+}
+
+// newYamlValueNode encodes the value, a string keeps the quotes of the scalar it replaces.
+func newYamlValueNode(value interface{}, old *yaml.Node) (*yaml.Node, error) {
+	node := &yaml.Node{}
+
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	quoted := old.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0
+
+	if node.Kind == yaml.ScalarNode && node.Tag == yamlStringTag && old.Kind == yaml.ScalarNode && quoted {
+		node.Style = old.Style & (yaml.SingleQuotedStyle | yaml.DoubleQuotedStyle)
+	}
+
+	return node, nil
+}
+
+// splice replaces the source of the old scalar with the new one, it fails when either
+// of them isn't written on a single line.
+func (d *yamlDocument) splice(old *yaml.Node, node *yaml.Node) ([]byte, bool) {
+	if old.Kind != yaml.ScalarNode || node.Kind != yaml.ScalarNode || old.Anchor != "" || old.Style&yaml.TaggedStyle != 0 {
+		return nil, false
+	}
+
+	start := yamlOffset(d.stream, old.Line, old.Column)
+	if start == -1 {
+		return nil, false
+	}
+
+	end := yamlScalarEnd(d.stream, start, old)
+	if end == -1 {
+		return nil, false
+	}
+
+	text, err := yaml.Marshal(node)
+	if err != nil {
+		return nil, false
+	}
+
+	text = bytes.TrimSuffix(text, []byte("\n"))
+	if len(text) == 0 || bytes.ContainsAny(text, "\r\n") {
+		return nil, false
+	}
+
+	stream := make([]byte, 0, len(d.stream)-(end-start)+len(text))
+	stream = append(stream, d.stream[:start]...)
+	stream = append(stream, text...)
+	stream = append(stream, d.stream[end:]...)
+
+	return stream, true
+}
+
+// yamlOffset returns the byte offset of the 1-based line and column, the columns of yaml count characters.
+func yamlOffset(stream []byte, line int, column int) int {
+	offset := 0
+
+	for l := 1; l < line; l++ {
+		idx := bytes.IndexByte(stream[offset:], '\n')
+		if idx == -1 {
+			return -1
+		}
+
+		offset += idx + 1
+	}
+
+	for c := 1; c < column; c++ {
+		if offset >= len(stream) || stream[offset] == '\n' {
+			return -1
+		}
+
+		_, size := utf8.DecodeRune(stream[offset:])
+		offset += size
+	}
+
+	return offset
+}
+
+// yamlScalarEnd returns the offset following the scalar which starts at the offset,
+// it returns -1 when the scalar isn't written on a single line.
+func yamlScalarEnd(stream []byte, start int, node *yaml.Node) int {
+	if start >= len(stream) {
+		return -1
+	}
+
+	switch node.Style {
+	case 0, yaml.FlowStyle:
+		if node.Value == "" || strings.ContainsAny(node.Value, "\r\n") || !bytes.HasPrefix(stream[start:], []byte(node.Value)) {
+			return -1
+		}
+
+		return start + len(node.Value)
+	case yaml.DoubleQuotedStyle:
+		if stream[start] != '"' {
+			return -1
+		}
+
+		for i := start + 1; i < len(stream); i++ {
+			switch stream[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			case '\n':
+				return -1
+			}
+		}
+	case yaml.SingleQuotedStyle:
+		if stream[start] != '\'' {
+			return -1
+		}
+
+		for i := start + 1; i < len(stream); i++ {
+			switch stream[i] {
+			case '\'':
+				if i+1 < len(stream) && stream[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			case '\n':
+				return -1
+			}
+		}
+	}
+
+	return -1
+}
+
+// encode re-encodes the node tree with the indentation and the line endings of the source.
+func (d *yamlDocument) encode() error {
+	clearYamlMergeTags(d.root)
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(detectYamlIndent(d.stream))
+
+	if err := encoder.Encode(d.root); err != nil {
+		return err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	stream := buf.Bytes()
+
+	if bytes.Contains(d.stream, []byte(yamlWindowsLineEnding)) {
+		stream = bytes.Replace(stream, []byte("\n"), []byte(yamlWindowsLineEnding), -1)
+	}
+
+	return d.reset(stream)
+}
+
+// encodeYamlValue encodes the value through a node tree with the default indentation.
+func encodeYamlValue(value interface{}) ([]byte, error) {
+	var node yaml.Node
+
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(yamlDefaultIndent)
+
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// clearYamlMergeTags clears the tags of the merge keys, which the encoder would write as "!!merge <<".
+func clearYamlMergeTags(node *yaml.Node) {
+	if isYamlMergeKey(node) {
+		node.Tag = ""
+	}
+
+	for _, child := range node.Content {
+		clearYamlMergeTags(child)
+	}
+}
+
+// detectYamlIndent returns the smallest indentation of the source.
+func detectYamlIndent(stream []byte) int {
+	indent := 0
+
+	for _, line := range strings.Split(string(stream), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		n := len(line) - len(trimmed)
+
+		if n == 0 || strings.TrimSpace(trimmed) == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if indent == 0 || n < indent {
+			indent = n
+		}
+	}
+
+	if indent < yamlDefaultIndent || indent > yamlMaxIndent {
+		return yamlDefaultIndent
+	}
+
+	return indent
+}
+
+// patchYamlDocument updates the original document to match the flattened key/value map,
+// the keys which are already up to date are left untouched.
+func patchYamlDocument(original []byte, data map[string]interface{}) ([]byte, error) {
+	doc, err := parseYamlDocument(original)
+	if err != nil {
+		return nil, err
+	}
+
+	for pass := 0; pass < maxYamlPatchPasses; pass++ {
+		current, err := doc.Flatten(RootPath)
+		if err != nil {
+			return nil, err
+		}
+
+		updated := make([]string, 0)
+		removed := make([]string, 0)
+
+		for k, v := range data {
+			if c, exist := current[k]; !exist || !isYamlValueEqual(c, v) {
+				updated = append(updated, k)
+			}
+		}
+
+		for _, k := range collapseRemovedKeys(current, data) {
+			removed = append(removed, k)
+		}
+
+		if len(updated) == 0 && len(removed) == 0 {
+			return doc.Bytes(), nil
+		}
+
+		sortPaths(updated)
+		sortPaths(removed)
+
+		for _, k := range updated {
+			if err := doc.Set(k, data[k]); err != nil {
+				return nil, err
+			}
+		}
+
+		// the elements are removed from the last one, so the indexes of the others don't shift
+		for i := len(removed) - 1; i >= 0; i-- {
+			if err := doc.Remove(removed[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return nil, errors.New("[patchYamlDocument] the document can't be updated to match the configuration")
+}
+
+// collapseRemovedKeys returns the keys of current which aren't in data, a mapping or a sequence
+// whose keys are all removed is returned instead of its keys.
+func collapseRemovedKeys(current map[string]interface{}, data map[string]interface{}) []string {
+	kept := make(map[string]bool)
+
+	for k := range data {
+		for _, p := range yamlKeyPaths(k) {
+			kept[p] = true
+		}
+	}
+
+	removed := make(map[string]bool)
+
+	for k := range current {
+		if _, exist := data[k]; exist {
+			continue
+		}
+
+		for _, p := range yamlKeyPaths(k) {
+			if !kept[p] {
+				removed[p] = true
+				break
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(removed))
+	for k := range removed {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// yamlKeyPaths returns the paths from the first entity of the key down to the key itself.
+func yamlKeyPaths(key string) []string {
+	entities := NewStringSplitter(key).Split(PathDelimiter, true)
+	paths := make([]string, len(entities))
+
+	for i := range entities {
+		paths[i] = PathCombine(append([]string{RootPath}, entities[:i+1]...)...)
+	}
+
+	return paths
+}
+
+// isYamlValueEqual compares the values, the numbers of different types are equal when they are written the same.
+func isYamlValueEqual(a interface{}, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	return isNumericValue(a) && isNumericValue(b) && fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func isNumericValue(value interface{}) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
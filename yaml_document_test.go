@@ -0,0 +1,153 @@
+package gconf
+
+import (
+	"strings"
+	"testing"
+)
+
+const commentedYaml = `# the settings of the service
+service:
+  name: "orders"   # quoted on purpose
+  port: 8080
+
+defaults: &defaults
+  timeout: 30
+  retries: 3
+
+# the clients share the defaults
+clients:
+  - <<: *defaults
+    host: alpha
+  - <<: *defaults
+    host: beta
+    timeout: 60
+`
+
+func TestYamlDocumentScalarUpdate(t *testing.T) {
+	data, err := yamlFileFormat{}.Parse([]byte(commentedYaml))
+	if err != nil {
+		t.Fatalf("can't parse the yaml: %s", err.Error())
+	}
+
+	if data["/clients/$0/timeout"] != 30 || data["/clients/$1/timeout"] != 60 {
+		t.Errorf("merged keys of the clients, returned: %v", data)
+	}
+
+	data["/service/port"] = 9090
+	data["/service/name"] = "billing"
+
+	stream, err := yamlFileFormat{}.Patch([]byte(commentedYaml), data)
+	if err != nil {
+		t.Fatalf("can't patch the yaml: %s", err.Error())
+	}
+
+	expected := strings.Replace(commentedYaml, "port: 8080", "port: 9090", 1)
+	expected = strings.Replace(expected, `"orders"`, `"billing"`, 1)
+
+	if string(stream) != expected {
+		t.Errorf("patched yaml, expected: %q, returned: %q", expected, string(stream))
+	}
+}
+
+func TestYamlDocumentStructuralUpdate(t *testing.T) {
+	data, err := yamlFileFormat{}.Parse([]byte(commentedYaml))
+	if err != nil {
+		t.Fatalf("can't parse the yaml: %s", err.Error())
+	}
+
+	data["/clients/$0/timeout"] = 10
+	data["/service/tags/$0"] = "internal"
+	delete(data, "/clients/$1/host")
+	delete(data, "/clients/$1/timeout")
+	delete(data, "/clients/$1/retries")
+
+	stream, err := yamlFileFormat{}.Patch([]byte(commentedYaml), data)
+	if err != nil {
+		t.Fatalf("can't patch the yaml: %s", err.Error())
+	}
+
+	patched, err := yamlFileFormat{}.Parse(stream)
+	if err != nil {
+		t.Fatalf("can't parse the patched yaml: %s", err.Error())
+	}
+
+	if len(patched) != len(data) {
+		t.Errorf("keys of the patched yaml, expected: %v, returned: %v", data, patched)
+	}
+
+	for k, v := range data {
+		if patched[k] != v {
+			t.Errorf("value of the key[%s], expected: %v, returned: %v", k, v, patched[k])
+		}
+	}
+
+	for _, kept := range []string{"# the settings of the service", "# quoted on purpose", "# the clients share the defaults", "&defaults", "*defaults"} {
+		if !strings.Contains(string(stream), kept) {
+			t.Errorf("the patched yaml lost %q: %s", kept, string(stream))
+		}
+	}
+
+	if strings.Contains(string(stream), "!!merge") {
+		t.Errorf("the patched yaml has the tags of the merge keys: %s", string(stream))
+	}
+
+	if strings.Index(string(stream), "service:") > strings.Index(string(stream), "clients:") {
+		t.Errorf("the patched yaml lost the order of the keys: %s", string(stream))
+	}
+}
+
+func TestYamlBooleans(t *testing.T) {
+	stream := "enabled: yes\ndebug: off\ntrace: On\nname: \"yes\"\nmode: !!str on\nanswer: no way\n"
+
+	data, err := yamlFileFormat{}.Parse([]byte(stream))
+	if err != nil {
+		t.Fatalf("can't parse the yaml: %s", err.Error())
+	}
+
+	expected := map[string]interface{}{
+		"/enabled": true,
+		"/debug":   false,
+		"/trace":   true,
+		"/name":    "yes",
+		"/mode":    "on",
+		"/answer":  "no way",
+	}
+
+	for key, value := range expected {
+		if data[key] != value {
+			t.Errorf("yaml value of %s, expected: %v, returned: %v", key, value, data[key])
+		}
+	}
+}
+
+func TestYamlEncode(t *testing.T) {
+	data := map[string]interface{}{
+		"/service/name":      "orders",
+		"/service/port":      8080,
+		"/clients/$0/host":   "alpha",
+		"/clients/$1/host":   "beta",
+		"/clients/$1/secure": true,
+	}
+
+	stream, err := yamlFileFormat{}.Encode(data)
+	if err != nil {
+		t.Fatalf("can't encode the data: %s", err.Error())
+	}
+
+	expected := "clients:\n  - host: alpha\n  - host: beta\n    secure: true\nservice:\n  name: orders\n  port: 8080\n"
+
+	if string(stream) != expected {
+		t.Errorf("encoded document, expected: %q, returned: %q", expected, string(stream))
+	}
+
+	parsed, err := yamlFileFormat{}.Parse(stream)
+	if err != nil {
+		t.Fatalf("can't parse the encoded document: %s", err.Error())
+	}
+
+	for k, v := range data {
+		if parsed[k] != v {
+			t.Errorf("value of the key[%s] after the round trip, expected: %v, returned: %v", k, v, parsed[k])
+		}
+	}
+}